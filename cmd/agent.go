@@ -0,0 +1,507 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/diff"
+	"github.com/tedfulk/suggest/internal/tools"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+var agentForceFlag bool
+
+// agentChange is a file change made during an agent session, recorded so it
+// can be undone.
+type agentChange struct {
+	Path    string `json:"path"`
+	Existed bool   `json:"existed"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+}
+
+// agentUndoLog records the changes of the last agent session.
+type agentUndoLog struct {
+	Task      string        `json:"task"`
+	Dir       string        `json:"dir"`
+	StartedAt time.Time     `json:"started_at"`
+	Changes   []agentChange `json:"changes"`
+}
+
+// agentSession proposes file changes to the user and logs the approved ones.
+type agentSession struct {
+	root string
+	log  agentUndoLog
+}
+
+const agentSystemPrompt = `You are a coding agent working in the directory %s.
+Use read_file, list_dir and grep to understand the code before changing it.
+Make targeted changes with edit_file and create or rewrite whole files with write_file.
+Every change is shown to the user as a diff to approve, reject or edit; the tool result tells you what was applied.
+Paths are relative to the working directory and cannot leave it.
+When you are done, briefly summarize what you changed.`
+
+var agentCmd = &cobra.Command{
+	Use:   "agent [task]",
+	Short: "Let the model edit files in the current directory, with your approval",
+	Long: `Give the model a task it can carry out by reading and editing files in the
+current directory.
+
+Every change the model proposes is shown as a unified diff, hunk by hunk,
+for you to approve, reject or edit in $EDITOR before it is written. The
+model cannot read or write outside the current directory.
+
+Approved changes are recorded in an undo log; 'suggest agent undo' reverts
+the changes of the last session.
+
+Example:
+  suggest agent "Add a --verbose flag to the build command"
+  suggest agent -m gpt-4o "Write unit tests for internal/diff"
+  go test ./... 2>&1 | suggest agent "Fix the failing tests"
+  suggest agent undo`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		task, err := readMessage(args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if strings.TrimSpace(task) == "" {
+			fmt.Println("Please describe the task via arguments or pipe content. Use --help for more information.")
+			return
+		}
+		// Changes are approved at the terminal, even when the task was piped
+		if err := reattachTerminal(); err != nil {
+			fmt.Printf("Error: approving changes needs a terminal: %v\n", err)
+			return
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		model := cfg.Model
+		if modelFlag != "" {
+			model = modelFlag
+		}
+
+		provider, model, err := config.ResolveModel(model, cfg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		root, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		session := &agentSession{
+			root: root,
+			log: agentUndoLog{
+				Task:      task,
+				Dir:       root,
+				StartedAt: time.Now(),
+			},
+		}
+		registry := tools.NewRegistry(tools.ReadOnly(root)...)
+		registry.Add(session.editTools()...)
+
+		req := &api.ChatCompletionRequest{
+			Model:    model,
+			Messages: buildMessages(fmt.Sprintf(agentSystemPrompt, root), task),
+		}
+		params := generationParams(cmd, cfg, api.Float(0.1))
+		params(provider, req)
+
+		fmt.Printf("Working on it with %s...\n", cyan(model))
+		resp, _, apiErr := completeWithTools(cfg, provider, req, registry, params)
+		if apiErr != nil {
+			fmt.Printf("Error: %v\n", apiErr)
+		} else if len(resp.Choices) > 0 {
+			fmt.Print(renderWithGlamour(resp.Choices[0].Message.Content))
+		}
+
+		if len(session.log.Changes) == 0 {
+			fmt.Println("No files were changed")
+			return
+		}
+		fmt.Println("Changed files:")
+		for _, c := range session.log.Changes {
+			fmt.Printf("  %s\n", green(session.relative(c.Path)))
+		}
+		fmt.Printf("Run %s to revert these changes\n", cyan("suggest agent undo"))
+	},
+}
+
+var agentUndoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the file changes of the last agent session",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log, err := loadAgentUndoLog()
+		if err != nil {
+			fmt.Println("Error reading undo log:", err)
+			return
+		}
+		if log == nil || len(log.Changes) == 0 {
+			fmt.Println("Nothing to undo")
+			return
+		}
+
+		fmt.Printf("Undoing %d changes from %s (%s)\n", len(log.Changes), cyan(truncateRunes(log.Task, 60)), formatAge(log.StartedAt))
+
+		// Revert in reverse order so files changed more than once end up
+		// in their original state
+		var remaining []agentChange
+		for i := len(log.Changes) - 1; i >= 0; i-- {
+			c := log.Changes[i]
+			current, err := os.ReadFile(c.Path)
+			if err == nil && string(current) != c.After && !agentForceFlag {
+				fmt.Printf("%s %s was modified after the agent changed it, use --force to revert it anyway\n", yellow("Skipping"), c.Path)
+				remaining = append([]agentChange{c}, remaining...)
+				continue
+			}
+
+			if !c.Existed {
+				if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+					fmt.Printf("Error removing %s: %v\n", c.Path, err)
+					remaining = append([]agentChange{c}, remaining...)
+					continue
+				}
+				fmt.Printf("Removed %s\n", c.Path)
+				continue
+			}
+			if err := os.WriteFile(c.Path, []byte(c.Before), 0644); err != nil {
+				fmt.Printf("Error restoring %s: %v\n", c.Path, err)
+				remaining = append([]agentChange{c}, remaining...)
+				continue
+			}
+			fmt.Printf("Restored %s\n", c.Path)
+		}
+
+		log.Changes = remaining
+		if err := saveAgentUndoLog(log); err != nil {
+			fmt.Println("Error saving undo log:", err)
+		}
+	},
+}
+
+// editTools returns the tools the model uses to propose file changes.
+func (s *agentSession) editTools() []tools.Tool {
+	return []tools.Tool{
+		{
+			Name:        "edit_file",
+			Description: "Replace an exact snippet of a file with new text. old_text must appear exactly once in the file; include enough surrounding lines to make it unique.",
+			Parameters: tools.Object(map[string]string{
+				"path":     "Path of the file to edit",
+				"old_text": "The exact text to replace",
+				"new_text": "The text to put in its place",
+			}, "path", "old_text", "new_text"),
+			Run: s.editFile,
+		},
+		{
+			Name:        "write_file",
+			Description: "Create a file, or replace the entire contents of an existing one",
+			Parameters: tools.Object(map[string]string{
+				"path":    "Path of the file to write",
+				"content": "The complete new contents of the file",
+			}, "path", "content"),
+			Run: s.writeFile,
+		},
+	}
+}
+
+func (s *agentSession) editFile(args map[string]interface{}) (string, error) {
+	path, err := tools.StringArg(args, "path", true)
+	if err != nil {
+		return "", err
+	}
+	oldText, err := tools.StringArg(args, "old_text", true)
+	if err != nil {
+		return "", err
+	}
+	newText, err := tools.StringArg(args, "new_text", false)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := tools.ResolvePath(s.root, path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	before := string(data)
+	switch n := strings.Count(before, oldText); n {
+	case 0:
+		return "", fmt.Errorf("old_text was not found in %s, read the file again and copy the text exactly", path)
+	case 1:
+	default:
+		return "", fmt.Errorf("old_text appears %d times in %s, include more surrounding lines", n, path)
+	}
+
+	return s.propose(resolved, before, strings.Replace(before, oldText, newText, 1), true)
+}
+
+func (s *agentSession) writeFile(args map[string]interface{}) (string, error) {
+	path, err := tools.StringArg(args, "path", true)
+	if err != nil {
+		return "", err
+	}
+	content, err := tools.StringArg(args, "content", false)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := tools.ResolvePath(s.root, path)
+	if err != nil {
+		return "", err
+	}
+
+	before := ""
+	existed := true
+	data, err := os.ReadFile(resolved)
+	switch {
+	case err == nil:
+		before = string(data)
+	case os.IsNotExist(err):
+		existed = false
+	default:
+		return "", err
+	}
+
+	return s.propose(resolved, before, content, existed)
+}
+
+// propose shows the change from before to after hunk by hunk, writes the
+// approved hunks and returns a summary for the model.
+func (s *agentSession) propose(path, before, after string, existed bool) (string, error) {
+	rel := s.relative(path)
+	oldLines := diff.SplitLines(before)
+	hunks := diff.Hunks(diff.Lines(oldLines, diff.SplitLines(after)), diff.DefaultContext)
+	if len(hunks) == 0 {
+		return fmt.Sprintf("No changes: the new content of %s is identical to the current one.", rel), nil
+	}
+
+	action := "Proposed change to"
+	if !existed {
+		action = "Proposed new file"
+	}
+	fmt.Printf("\n%s %s\n", yellow(action), cyan(rel))
+
+	var approved []diff.Hunk
+	edited := false
+	decided := ""
+	for i, h := range hunks {
+		printHunk(h)
+
+		choice := decided
+		if choice == "" {
+			prompt := promptui.Select{
+				Label: fmt.Sprintf("Hunk %d of %d", i+1, len(hunks)),
+				Items: []string{"Approve", "Reject", "Edit", "Approve all remaining", "Reject all remaining"},
+				Templates: &promptui.SelectTemplates{
+					Label:    "{{ . }}",
+					Active:   "\U0001F449 {{ . | cyan }}",
+					Inactive: "  {{ . | white }}",
+					Selected: "\U00002705 {{ . | green }}",
+				},
+			}
+			_, result, err := prompt.Run()
+			if err != nil {
+				// Treat an interrupted prompt as rejecting the rest
+				result = "Reject all remaining"
+			}
+			choice = result
+		}
+
+		switch choice {
+		case "Approve all remaining":
+			decided = choice
+			approved = append(approved, h)
+		case "Approve":
+			approved = append(approved, h)
+		case "Edit":
+			lines, err := editInEditor(h.NewText(), filepath.Ext(path))
+			if err != nil {
+				fmt.Printf("Error editing hunk: %v\n", err)
+				continue
+			}
+			h.Replace(lines)
+			approved = append(approved, h)
+			edited = true
+		case "Reject all remaining":
+			decided = choice
+		}
+	}
+
+	if len(approved) == 0 {
+		return fmt.Sprintf("The user rejected the change to %s.", rel), nil
+	}
+
+	result := after
+	if len(approved) < len(hunks) || edited {
+		result = diff.JoinLines(diff.Apply(oldLines, approved))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, []byte(result), mode); err != nil {
+		return "", err
+	}
+
+	s.log.Changes = append(s.log.Changes, agentChange{
+		Path:    path,
+		Existed: existed,
+		Before:  before,
+		After:   result,
+	})
+	if err := saveAgentUndoLog(&s.log); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save undo log: %v\n", err)
+	}
+
+	summary := fmt.Sprintf("Applied the change to %s.", rel)
+	if len(approved) < len(hunks) {
+		summary = fmt.Sprintf("Applied %d of %d hunks to %s; the user rejected the rest.", len(approved), len(hunks), rel)
+	}
+	if edited {
+		summary += " The user edited some hunks, read the file again before changing it further."
+	}
+	return summary, nil
+}
+
+func (s *agentSession) relative(path string) string {
+	if rel, err := filepath.Rel(s.root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func printHunk(h diff.Hunk) {
+	fmt.Println(cyan(h.Header()))
+	for _, op := range h.Ops {
+		line := string(op.Kind) + op.Line
+		switch op.Kind {
+		case '-':
+			fmt.Println(red(line))
+		case '+':
+			fmt.Println(green(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+// editInEditor opens lines in $VISUAL or $EDITOR, falling back to vi, and
+// returns the edited lines.
+func editInEditor(lines []string, ext string) ([]string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "suggest-hunk-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(diff.JoinLines(lines)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.Close()
+
+	// The editor may carry arguments, e.g. "code --wait"
+	editorCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	return diff.SplitLines(string(data)), nil
+}
+
+func agentUndoLogPath() (string, error) {
+	cacheDir, err := config.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "agent_undo.json"), nil
+}
+
+// loadAgentUndoLog returns the undo log of the last session, or nil when
+// there is none.
+func loadAgentUndoLog() (*agentUndoLog, error) {
+	path, err := agentUndoLogPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var log agentUndoLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func saveAgentUndoLog(log *agentUndoLog) error {
+	path, err := agentUndoLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func init() {
+	agentCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Specify the model to use")
+	addGenerationFlags(agentCmd)
+	agentUndoCmd.Flags().BoolVar(&agentForceFlag, "force", false, "Revert files even if they were modified after the agent changed them")
+	agentCmd.AddCommand(agentUndoCmd)
+	rootCmd.AddCommand(agentCmd)
+}
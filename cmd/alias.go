@@ -31,38 +31,11 @@ var aliasAddCmd = &cobra.Command{
 			return
 		}
 
-		// Determine the provider based on the model name
-		provider := config.DetermineModelProvider(model, cfg)
-		if provider == "" {
-			fmt.Printf("Warning: Unable to determine provider for model '%s'\n", model)
+		// Validate the model against the configured providers' model lists
+		if provider, resolved, err := config.ResolveModel(model, cfg); err != nil {
+			fmt.Printf("Warning: %v\n", err)
 		} else {
-			// Convert string provider to Provider type
-			var providerType config.Provider
-			switch provider {
-			case "openai":
-				providerType = config.ProviderOpenAI
-			case "groq":
-				providerType = config.ProviderGroq
-			case "gemini":
-				providerType = config.ProviderGemini
-			}
-
-			// Fetch models from the provider to validate
-			models, err := config.FetchModels(providerType, cfg)
-			if err != nil {
-				fmt.Printf("Warning: Could not validate model: %v\n", err)
-			} else {
-				modelExists := false
-				for _, m := range models {
-					if m == model {
-						modelExists = true
-						break
-					}
-				}
-				if !modelExists {
-					fmt.Printf("Warning: Model '%s' is not in the list of available models\n", model)
-				}
-			}
+			fmt.Printf("Model '%s' is served by %s\n", resolved, provider)
 		}
 
 		cfg.ModelAliases[alias] = model
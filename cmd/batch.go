@@ -0,0 +1,495 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchOutput      string
+	batchConcurrency int
+	batchRateLimits  map[string]int
+	batchRetryErrors bool
+)
+
+// batchMaxRetries is how often a request that failed with a rate limit or
+// server error is retried before the error is recorded.
+const batchMaxRetries = 3
+
+// batchInput is one line of a batch input file.
+type batchInput struct {
+	ID       interface{}            `json:"id,omitempty"`
+	Prompt   string                 `json:"prompt"`
+	Model    string                 `json:"model,omitempty"`
+	System   string                 `json:"system,omitempty"`
+	Template string                 `json:"template,omitempty"`
+	Vars     map[string]interface{} `json:"vars,omitempty"`
+}
+
+// batchUsage is the token usage of a batch request.
+type batchUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// batchResult is one line of a batch output file.
+type batchResult struct {
+	ID        string          `json:"id"`
+	Line      int             `json:"line"`
+	Model     string          `json:"model,omitempty"`
+	Provider  config.Provider `json:"provider,omitempty"`
+	Response  string          `json:"response,omitempty"`
+	Usage     batchUsage      `json:"usage"`
+	LatencyMs int64           `json:"latency_ms"`
+	Error     string          `json:"error,omitempty"`
+	// canceled is set when Ctrl+C stopped the job before it got an answer.
+	// Such results aren't written, so the next run picks the job up again.
+	canceled bool
+}
+
+// batchJob is an input line waiting to be run.
+type batchJob struct {
+	line  int
+	id    string
+	input batchInput
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <input.jsonl>",
+	Short: "Run every prompt of a JSONL file and write the answers to another",
+	Long: `Run the prompts of a JSONL file concurrently and write one JSON line per
+answer to the output file.
+
+Each input line is an object with a prompt and, optionally, an id, a model
+(ID, provider/model or alias), a system prompt title, a template title and
+template variables:
+
+  {"id": "q1", "prompt": "Classify: I love it"}
+  {"id": "q2", "model": "fast", "system": "Programming Assistant", "template": "Code Function", "vars": {"language": "Go", "task": "reverses a string"}}
+
+Lines without an id are identified by their line number. The prompt is
+appended to the template when both are given. -m and -s set the model and
+system prompt of lines that don't name their own.
+
+Each output line records the id, model, provider, response, token usage,
+latency and any error, in the order the requests finish. Output is appended,
+so an interrupted run picks up where it stopped: ids already in the output
+file are skipped, and with --retry-errors only the ones that succeeded.
+
+Requests that hit a rate limit or server error are retried with a backoff.
+Requests per minute can be capped for each provider with --rate-limit or in
+the config:
+
+  rate_limits:
+    groq: 30
+    openai: 500
+
+Example:
+  suggest batch prompts.jsonl -o answers.jsonl
+  suggest batch prompts.jsonl -o answers.jsonl -m gpt-4o-mini -c 8
+  suggest batch prompts.jsonl -o answers.jsonl --rate-limit groq=30 --retry-errors`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if batchOutput == "" {
+			fmt.Println("Please provide an output file with -o")
+			return
+		}
+		if batchConcurrency < 1 {
+			fmt.Println("Concurrency must be at least 1")
+			return
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		jobs, err := readBatchInput(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		done, err := readBatchDone(batchOutput, batchRetryErrors)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		var pending []batchJob
+		for _, job := range jobs {
+			if !done[job.id] {
+				pending = append(pending, job)
+			}
+		}
+		if skipped := len(jobs) - len(pending); skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Skipping %d prompts already in %s\n", skipped, batchOutput)
+		}
+		if len(pending) == 0 {
+			fmt.Println("Nothing to do")
+			return
+		}
+
+		out, err := openBatchOutput(batchOutput)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		defer out.Close()
+
+		var names []string
+		for _, job := range pending {
+			names = append(names, job.modelName(cfg))
+		}
+		resolved := resolveModels(cfg, names...)
+
+		limits := make(map[string]int)
+		for provider, rpm := range cfg.RateLimits {
+			limits[provider] = rpm
+		}
+		for provider, rpm := range batchRateLimits {
+			limits[provider] = rpm
+		}
+		limiter := newProviderLimiter(limits)
+
+		// On Ctrl+C no new requests are started, but the ones in flight are
+		// still written so nothing is lost. Jobs that were waiting are left
+		// out of the output file so the next run retries them.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		queue := make(chan batchJob)
+		go func() {
+			defer close(queue)
+			for _, job := range pending {
+				select {
+				case queue <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		var (
+			mu       sync.Mutex
+			finished int
+			failed   int
+			writeErr error
+		)
+		var wg sync.WaitGroup
+		for i := 0; i < batchConcurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range queue {
+					result := runBatchJob(ctx, cmd, cfg, resolved, limiter, job)
+					if result.canceled {
+						continue
+					}
+
+					mu.Lock()
+					finished++
+					if result.Error != "" {
+						failed++
+					}
+					if err := writeBatchResult(out, result); err != nil && writeErr == nil {
+						writeErr = err
+					}
+					fmt.Fprintf(os.Stderr, "\r%s %d/%d done, %d failed", faint("Batch:"), finished, len(pending), failed)
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		fmt.Fprintln(os.Stderr)
+
+		if writeErr != nil {
+			fmt.Printf("Error writing results: %v\n", writeErr)
+			return
+		}
+		if ctx.Err() != nil {
+			fmt.Printf("Interrupted after %d of %d prompts. Run the same command again to continue.\n", finished, len(pending))
+			return
+		}
+		fmt.Printf("Wrote %d results to %s (%d failed)\n", finished, batchOutput, failed)
+	},
+}
+
+// readBatchInput parses the input file, skipping blank lines.
+func readBatchInput(path string) ([]batchJob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var jobs []batchJob
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var input batchInput
+		if err := json.Unmarshal([]byte(text), &input); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid JSON: %w", path, line, err)
+		}
+		if strings.TrimSpace(input.Prompt) == "" && input.Template == "" {
+			return nil, fmt.Errorf("%s:%d: needs a prompt or a template", path, line)
+		}
+
+		id := strconv.Itoa(line)
+		if input.ID != nil {
+			id = fmt.Sprint(input.ID)
+		}
+		if previous, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%s:%d: id '%s' is already used on line %d", path, line, id, previous)
+		}
+		seen[id] = line
+
+		jobs = append(jobs, batchJob{line: line, id: id, input: input})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return jobs, nil
+}
+
+// readBatchDone returns the ids already recorded in the output file. With
+// retryErrors, failed results don't count as done.
+func readBatchDone(path string, retryErrors bool) (map[string]bool, error) {
+	done := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var result batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			continue // A line cut short by an interruption
+		}
+		if result.Error == "" || !retryErrors {
+			done[result.ID] = true
+		} else if !done[result.ID] {
+			// A later successful retry wins over an earlier failure
+			done[result.ID] = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return done, nil
+}
+
+// openBatchOutput opens the output file for appending, making sure new
+// results start on a fresh line.
+func openBatchOutput(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err != nil && err != io.EOF {
+			f.Close()
+			return nil, err
+		}
+		if last[0] != '\n' {
+			if _, err := f.Write([]byte("\n")); err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+	}
+	return f, nil
+}
+
+func writeBatchResult(w io.Writer, result batchResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// modelName returns the model of the line, else -m, else the default.
+func (job batchJob) modelName(cfg *config.Config) string {
+	if job.input.Model != "" {
+		return job.input.Model
+	}
+	if modelFlag != "" {
+		return modelFlag
+	}
+	return cfg.Model
+}
+
+// runBatchJob answers a single input line, retrying rate limits and server
+// errors with an exponential backoff.
+func runBatchJob(ctx context.Context, cmd *cobra.Command, cfg *config.Config, resolved resolvedModels, limiter *providerLimiter, job batchJob) batchResult {
+	result := batchResult{ID: job.id, Line: job.line}
+	input := job.input
+
+	vars := make(map[string]string)
+	for k, v := range input.Vars {
+		vars[k] = fmt.Sprint(v)
+	}
+	message, err := composeMessage(cfg, input.Template, vars, input.Prompt)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	systemTitle := input.System
+	if systemTitle == "" {
+		systemTitle = systemFlag
+	}
+	systemPrompt, err := resolveSystemPrompt(cfg, systemTitle)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	provider, model, err := resolved.get(job.modelName(cfg))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Provider = provider
+	result.Model = model
+
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: buildMessages(systemPrompt, message),
+	}
+	applyGenerationParams(cmd, cfg, provider, req, api.Float(0.7))
+
+	var resp *api.ChatCompletionResponse
+	backoff := 2 * time.Second
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx, provider); err != nil {
+			result.canceled = true
+			return result
+		}
+
+		start := time.Now()
+		resp, err = getResponse(provider, cfg, req)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		if err == nil || !api.IsRetryable(err) || attempt == batchMaxRetries {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			result.canceled = true
+			return result
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(resp.Choices) == 0 {
+		result.Error = "no response received from model"
+		return result
+	}
+
+	result.Response = resp.Choices[0].Message.Content
+	result.Usage = batchUsage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+	return result
+}
+
+// providerLimiter spaces out the requests to each provider to stay under
+// its requests-per-minute limit.
+type providerLimiter struct {
+	mu       sync.Mutex
+	interval map[config.Provider]time.Duration
+	next     map[config.Provider]time.Time
+}
+
+func newProviderLimiter(rpm map[string]int) *providerLimiter {
+	l := &providerLimiter{
+		interval: make(map[config.Provider]time.Duration),
+		next:     make(map[config.Provider]time.Time),
+	}
+	for provider, n := range rpm {
+		if n > 0 {
+			l.interval[config.Provider(provider)] = time.Minute / time.Duration(n)
+		}
+	}
+	return l
+}
+
+// wait blocks until a request to provider may be sent, or ctx is done.
+func (l *providerLimiter) wait(ctx context.Context, provider config.Provider) error {
+	l.mu.Lock()
+	interval, ok := l.interval[provider]
+	if !ok {
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+	now := time.Now()
+	slot := l.next[provider]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[provider] = slot.Add(interval)
+	l.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(slot)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func init() {
+	batchCmd.Flags().StringVarP(&batchOutput, "output", "o", "", "File to append the results to (required)")
+	batchCmd.Flags().IntVarP(&batchConcurrency, "concurrency", "c", 4, "Number of requests to run at once")
+	batchCmd.Flags().StringToIntVar(&batchRateLimits, "rate-limit", nil, "Requests per minute per provider (format: groq=30,openai=500)")
+	batchCmd.Flags().BoolVar(&batchRetryErrors, "retry-errors", false, "Run prompts again whose earlier result was an error")
+	batchCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Model for lines that don't name one")
+	batchCmd.Flags().StringVarP(&systemFlag, "system", "s", "", "System prompt title for lines that don't name one")
+	addGenerationFlags(batchCmd)
+	rootCmd.AddCommand(batchCmd)
+}
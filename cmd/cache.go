@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the response cache",
+	Long: `Manage the on-disk response cache.
+
+Identical requests (same provider, model, messages and generation
+parameters) can be answered from ~/.cache/suggest/responses instead of
+calling the provider again. The cache is off by default; enable it in
+config.yml or per invocation with --cache:
+
+  cache:
+    enabled: true
+    ttl: 24h
+    max_size_mb: 100
+
+Example:
+  cat file.go | suggest --cache -t "Code Review"
+  suggest --no-cache "Tell me a joke"
+  suggest cache stats
+  suggest cache clear`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show response cache statistics",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		responseCache := newResponseCache(cfg)
+		stats, err := responseCache.Stats()
+		if err != nil {
+			fmt.Println("Error reading cache:", err)
+			return
+		}
+
+		status := "disabled"
+		if responseCacheEnabled(cfg) {
+			status = "enabled"
+		}
+
+		fmt.Printf("Status: %s\n", status)
+		fmt.Printf("Location: %s\n", responseCache.Dir)
+		fmt.Printf("Entries: %d (%d expired)\n", stats.Entries, stats.Expired)
+		fmt.Printf("Size: %s of %s\n", formatBytes(stats.Bytes), formatBytes(responseCache.MaxBytes))
+		fmt.Printf("TTL: %s\n", responseCache.TTL)
+		if stats.Entries > 0 {
+			fmt.Printf("Oldest entry: %s\n", formatAge(stats.Oldest))
+			fmt.Printf("Newest entry: %s\n", formatAge(stats.Newest))
+		}
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached responses",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		removed, err := newResponseCache(cfg).Clear()
+		if err != nil {
+			fmt.Println("Error clearing cache:", err)
+			return
+		}
+
+		fmt.Printf("Removed %d cached responses\n", removed)
+	},
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1024*1024*1024))
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
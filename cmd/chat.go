@@ -8,6 +8,7 @@ import (
 
 	"github.com/tedfulk/suggest/internal/api"
 	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/tools"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/fatih/color"
@@ -25,7 +26,9 @@ Example:
   suggest chat
   suggest chat --model gpt-4
   suggest chat -m llama3.3-70b-versatile
-  suggest chat -s "Programming Assistant"`,
+  suggest chat -s "Programming Assistant"
+  suggest chat --temperature 0 --seed 42
+  suggest chat --tools  # Let the model read files, search and run commands`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.LoadConfig()
 		if err != nil {
@@ -38,8 +41,10 @@ Example:
 			model = modelFlag
 		}
 
-		if actualModel, exists := cfg.ModelAliases[model]; exists {
-			model = actualModel
+		provider, model, err := config.ResolveModel(model, cfg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
 		systemPrompt := cfg.SystemPrompt
@@ -66,6 +71,13 @@ Example:
 			})
 		}
 
+		var registry *tools.Registry
+		if toolsFlag {
+			var closeTools func()
+			registry, closeTools = newToolRegistry(cfg)
+			defer closeTools()
+		}
+
 		cyan := color.New(color.FgCyan).SprintFunc()
 		fmt.Printf("\nStarting chat session with %s\n", cyan(model))
 		blue := color.New(color.FgBlue).SprintFunc()
@@ -100,54 +112,26 @@ Example:
 			})
 
 			req := &api.ChatCompletionRequest{
-				Model:       model,
-				Messages:    messages,
-				Temperature: 0.1,
+				Model:    model,
+				Messages: messages,
 			}
+			params := generationParams(cmd, cfg, api.Float(0.1))
+			params(provider, req)
 
 			var resp *api.ChatCompletionResponse
+			var answeredBy string
 			var apiErr error
-
-			provider := config.DetermineModelProvider(model, cfg)
-			if provider == "" {
-				fmt.Printf("Model '%s' not supported. Please use a Groq, OpenAI, Gemini, or Ollama model.\n", model)
-				return
+			if registry != nil {
+				resp, answeredBy, apiErr = completeWithTools(cfg, provider, req, registry, params)
+			} else {
+				resp, _, answeredBy, apiErr = completeWithFallback(cfg, provider, req, params)
 			}
-
-			switch provider {
-			case "groq":
-				if cfg.GroqAPIKey == "" {
-					fmt.Println("Groq API key not set. Please set it in your config file.")
-					return
-				}
-				client := api.NewGroqClient(cfg.GroqAPIKey)
-				resp, apiErr = client.CreateChatCompletion(req)
-			
-			case "openai":
-				if cfg.OpenAIAPIKey == "" {
-					fmt.Println("OpenAI API key not set. Please set it in your config file.")
-					return
-				}
-				client := api.NewOpenAIClient(cfg.OpenAIAPIKey)
-				resp, apiErr = client.CreateChatCompletion(req)
-			
-			case "gemini":
-				if cfg.GeminiAPIKey == "" {
-					fmt.Println("Gemini API key not set. Please set it in your config file.")
-					return
-				}
-				client := api.NewGeminiClient(cfg.GeminiAPIKey)
-				resp, apiErr = client.CreateChatCompletion(req)
-			
-			case "ollama":
-				client := api.NewOllamaClient(cfg.OllamaHost)
-				resp, apiErr = client.CreateChatCompletion(req)
-			}
-
 			if apiErr != nil {
 				fmt.Printf("Error: %v\n", apiErr)
 				continue
 			}
+			// Keep any tool calls and results in the conversation
+			messages = req.Messages
 
 			if len(resp.Choices) > 0 {
 				output := resp.Choices[0].Message.Content
@@ -163,9 +147,9 @@ Example:
 				)
 				doc, err := r.Render(output)
 				if err != nil {
-					fmt.Printf("\n%s: %s\n\n", cyan(model), output)
+					fmt.Printf("\n%s: %s\n\n", cyan(answeredBy), output)
 				} else {
-					fmt.Printf("\n%s:\n%s\n", cyan(model), doc)
+					fmt.Printf("\n%s:\n%s\n", cyan(answeredBy), doc)
 				}
 			}
 		}
@@ -173,5 +157,9 @@ Example:
 }
 
 func init() {
+	chatCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Specify the model to use")
+	chatCmd.Flags().StringVarP(&systemFlag, "system", "s", "", "Use a specific system prompt by title")
+	chatCmd.Flags().BoolVar(&toolsFlag, "tools", false, "Let the model call local tools and the tools of configured MCP servers")
+	addGenerationFlags(chatCmd)
 	rootCmd.AddCommand(chatCmd)
 } 
\ No newline at end of file
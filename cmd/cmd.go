@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -10,16 +13,71 @@ import (
 	"github.com/tedfulk/suggest/internal/api"
 	"github.com/tedfulk/suggest/internal/config"
 
+	"github.com/aymanbagabas/go-osc52/v2"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 )
 
+// maxCapturedStderr bounds how much of a failed command's error output is
+// sent back to the model.
+const maxCapturedStderr = 4096
+
+// explainCommandSystemPrompt is used to explain a suggested command.
+const explainCommandSystemPrompt = "You explain shell commands. Describe what each part of the command does, any risks, and how it accomplishes the user's request. Use markdown."
+
+// defaultCommandSystemPrompt tells the model to reply with a bare command.
+// It can be replaced with cmd_system_prompt or --system.
+const defaultCommandSystemPrompt = "Return only the command to be executed as a raw string, no string delimiters wrapping it, no talking, no markdown, no fenced code blocks, what you return will be passed to subprocess.check_output() directly."
+
+var (
+	cmdAlternativesFlag int
+	cmdMaxFixesFlag     int
+	cmdPrintFlag        bool
+	cmdShellFlag        string
+	cmdNoContextFlag    bool
+)
+
 var cmdCmd = &cobra.Command{
 	Use:   "cmd [command description]",
 	Short: "Get command suggestions with interactive options",
+	Args:  cobra.ArbitraryArgs,
+	Long: `Turn a description into a shell command, then run, edit, copy or explain it,
+or ask a follow-up.
+
+The model is told about your OS and distribution, shell, the files in the
+current directory, the git status and which common tools are installed, so
+its commands fit your system. Pass --no-context to send none of it.
+
+When a command fails, its exit status and error output can be sent back to
+the model for a corrected command, up to --max-fixes times.
+
+With --alternatives the model suggests several commands, each with a short
+explanation, to pick from.
+
+With --print the command is printed without any prompts, for use in scripts
+and in the shell widgets of 'suggest shell-init'. Combined with
+--alternatives, the candidates are printed as a JSON array.
+
+Every suggested command is kept in a history; see 'suggest cmd history'.
+To describe a command starting with the word "history", quote the
+description or put -- before it: suggest cmd -- history of bash commands
+
+The model is --model, else cmd_model from the config, else the default
+model. --system picks a saved system prompt to use instead of the built-in
+one, which can also be replaced with cmd_system_prompt.
+
+Example:
+  suggest cmd "find all go files changed in the last week"
+  suggest cmd -m gpt-4o "rename every .jpeg file here to .jpg"
+  suggest cmd --alternatives 3 "show the largest files here"
+  suggest cmd --print "compress the logs directory"`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			fmt.Println("Please provide a command description")
+		if len(args) == 0 || strings.TrimSpace(strings.Join(args, " ")) == "" {
+			if cmdPrintFlag {
+				fmt.Fprintln(os.Stderr, "Please provide a command description")
+			} else {
+				fmt.Println("Please provide a command description")
+			}
 			return
 		}
 
@@ -29,17 +87,65 @@ var cmdCmd = &cobra.Command{
 			return
 		}
 
+		systemPrompt, err := commandSystemPrompt(cfg)
+		if err != nil {
+			if cmdPrintFlag {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
 		var messages []api.ChatMessage
-		systemPrompt := "Return only the command to be executed as a raw string, no string delimiters wrapping it, no talking, no markdown, no fenced code blocks, what you return will be passed to subprocess.check_output() directly."
-		
+		environment := ""
+		if !cmdNoContextFlag {
+			environment = commandContext(cmdShellFlag)
+			systemPrompt += "\n\n" + environment
+		}
+
 		messages = append(messages, api.ChatMessage{
 			Role:    "system",
 			Content: systemPrompt,
 		})
 
+		if cmdPrintFlag && cmdAlternativesFlag > 1 {
+			alternatives, err := requestAlternatives(cmd, cfg, environment, strings.Join(args, " "), cmdAlternativesFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			data, err := json.MarshalIndent(alternatives, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if cmdPrintFlag {
+			// Only the command goes to stdout, so shell widgets can insert it
+			messages = append(messages, api.ChatMessage{
+				Role:    "user",
+				Content: strings.Join(args, " "),
+			})
+			command, err := requestCommand(cmd, cfg, messages)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			recordCommand(strings.Join(args, " "), command, false, 0)
+			fmt.Println(command)
+			return
+		}
+
 		// Keep track of conversation history
 		var conversationHistory []api.ChatMessage
 		conversationHistory = append(conversationHistory, messages[0])
+		description := strings.Join(args, " ")
+		fixes := 0
+		first := true
 
 		for {
 			currentMessage := strings.Join(args, " ")
@@ -49,64 +155,129 @@ var cmdCmd = &cobra.Command{
 			})
 			conversationHistory = append(conversationHistory, messages[len(messages)-1])
 
-			req := &api.ChatCompletionRequest{
-				Model:    cfg.Model,
-				Messages: messages,
-			}
-
-			provider := config.DetermineModelProvider(cfg.Model, cfg)
-			resp, apiErr := getResponse(provider, cfg, req)
-			if apiErr != nil {
-				fmt.Printf("Error: %v\n", apiErr)
-				return
+			var command string
+			if first && cmdAlternativesFlag > 1 {
+				command, err = pickAlternative(cmd, cfg, environment, currentMessage, cmdAlternativesFlag)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+			} else {
+				command, err = requestCommand(cmd, cfg, messages)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
 			}
-
-			command := resp.Choices[0].Message.Content
+			first = false
 			conversationHistory = append(conversationHistory, api.ChatMessage{
 				Role:    "assistant",
 				Content: command,
 			})
 
-			fmt.Printf("\nCommand: %s\n\n", command)
-
-			prompt := promptui.Select{
-				Label: "Choose an action",
-				Items: []string{"Run", "Explain", "Follow up", "Exit"},
-				Templates: &promptui.SelectTemplates{
-					Label:    "{{ . }}",
-					Active:   "\U0001F449 {{ . | cyan }}", // 👉
-					Inactive: "  {{ . | white }}",
-					Selected: "\U00002705 {{ . | green }}", // ✅
-				},
-			}
+			var result string
+			for {
+				fmt.Printf("\nCommand: %s\n\n", command)
 
-			_, result, err := prompt.Run()
-			if err != nil {
-				fmt.Printf("Prompt failed %v\n", err)
-				return
+				prompt := promptui.Select{
+					Label: "Choose an action",
+					Items: []string{"Run", "Edit", "Copy", "Explain", "Follow up", "Exit"},
+					Templates: &promptui.SelectTemplates{
+						Label:    "{{ . }}",
+						Active:   "\U0001F449 {{ . | cyan }}", // 👉
+						Inactive: "  {{ . | white }}",
+						Selected: "\U00002705 {{ . | green }}", // ✅
+					},
+				}
+
+				_, result, err = prompt.Run()
+				if err != nil {
+					fmt.Printf("Prompt failed %v\n", err)
+					return
+				}
+				if result == "Explain" {
+					explanation, err := explainCommand(cmd, cfg, environment, conversationHistory)
+					if err != nil {
+						fmt.Printf("Error: %v\n", err)
+					} else {
+						fmt.Print(renderWithGlamour(explanation))
+					}
+					continue
+				}
+				if result != "Edit" {
+					break
+				}
+
+				edit := promptui.Prompt{
+					Label:     "Command",
+					Default:   command,
+					AllowEdit: true,
+				}
+				edited, err := edit.Run()
+				if err != nil {
+					fmt.Printf("Prompt failed %v\n", err)
+					return
+				}
+				if edited = strings.TrimSpace(edited); edited != "" {
+					command = edited
+					// Follow-ups and explanations are about the edited command
+					conversationHistory[len(conversationHistory)-1].Content = command
+				}
 			}
 
 			switch result {
 			case "Run":
-				cmd := exec.Command("sh", "-c", command)
-				output, err := cmd.CombinedOutput()
+				if !confirmCommandRisk(cfg, command, false) {
+					recordCommand(description, command, false, 0)
+					fmt.Println("Command not run")
+					return
+				}
+				code, stderr, err := runInShell(command)
 				if err != nil {
 					fmt.Printf("Error executing command: %v\n", err)
-				} else {
-					fmt.Printf("%s\n", output)
+					return
 				}
-				return
+				recordCommand(description, command, true, code)
+				if code == 0 {
+					fmt.Printf("\n%s\n", green("Exit status 0"))
+					return
+				}
+				fmt.Printf("\n%s\n", red(fmt.Sprintf("Exit status %d", code)))
+
+				if fixes >= cmdMaxFixesFlag {
+					if cmdMaxFixesFlag > 0 {
+						fmt.Printf("Reached the fix limit (--max-fixes %d)\n", cmdMaxFixesFlag)
+					}
+					return
+				}
+				fixPrompt := promptui.Select{
+					Label: "The command failed",
+					Items: []string{"Fix", "Exit"},
+				}
+				if _, choice, err := fixPrompt.Run(); err != nil || choice != "Fix" {
+					return
+				}
+				fixes++
 
-			case "Explain":
+				// Send the failure back so the model can correct its command
+				failure := fmt.Sprintf("The command failed with exit status %d.", code)
+				if strings.TrimSpace(stderr) != "" {
+					failure += fmt.Sprintf(" Its error output was:\n%s", stderr)
+				} else {
+					failure += " It printed no error output."
+				}
+				failure += "\nReturn a corrected command."
 				messages = make([]api.ChatMessage, len(conversationHistory))
 				copy(messages, conversationHistory)
-				messages = append(messages, api.ChatMessage{
-					Role:    "user",
-					Content: "Explain this command in detail and why it's relevant to my situation",
-				})
-				args = []string{"Explain this command in detail and why it's relevant to my situation"}
+				args = []string{failure}
 				continue
 
+			case "Copy":
+				recordCommand(description, command, false, 0)
+				copyToClipboard(command)
+				fmt.Println("Copied the command to the clipboard")
+				return
+
 			case "Follow up":
 				fmt.Print("\nEnter your follow-up question: ")
 				reader := bufio.NewReader(os.Stdin)
@@ -116,44 +287,284 @@ var cmdCmd = &cobra.Command{
 					return
 				}
 				followUp = strings.TrimSpace(followUp)
-				
+
 				messages = make([]api.ChatMessage, len(conversationHistory))
 				copy(messages, conversationHistory)
 				args = strings.Fields(followUp)
 				continue
 
 			case "Exit":
+				recordCommand(description, command, false, 0)
 				return
 			}
 		}
 	},
 }
 
-// Helper function to get response from the appropriate API
-func getResponse(provider string, cfg *config.Config, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
-	switch provider {
-	case "groq":
-		if cfg.GroqAPIKey == "" {
-			return nil, fmt.Errorf("groq API key not set")
+// resolveCommandModel returns the provider and model of --model, else of
+// cmd_model, else of the default model.
+func resolveCommandModel(cfg *config.Config) (config.Provider, string, error) {
+	name := cfg.Model
+	if cfg.CmdModel != "" {
+		name = cfg.CmdModel
+	}
+	if modelFlag != "" {
+		name = modelFlag
+	}
+	return config.ResolveModel(name, cfg)
+}
+
+// commandSystemPrompt returns the system prompt picked with --system, else
+// cmd_system_prompt, else the built-in one.
+func commandSystemPrompt(cfg *config.Config) (string, error) {
+	if systemFlag != "" {
+		return resolveSystemPrompt(cfg, systemFlag)
+	}
+	if strings.TrimSpace(cfg.CmdSystemPrompt) != "" {
+		return cfg.CmdSystemPrompt, nil
+	}
+	return defaultCommandSystemPrompt, nil
+}
+
+// explainCommand asks the command model to explain the last command of the
+// conversation. The answer is prose, so it is requested with its own system
+// prompt rather than the one asking for a bare command.
+func explainCommand(cmd *cobra.Command, cfg *config.Config, environment string, conversation []api.ChatMessage) (string, error) {
+	provider, model, err := resolveCommandModel(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	systemPrompt := explainCommandSystemPrompt
+	if environment != "" {
+		systemPrompt += "\n\n" + environment
+	}
+	messages := []api.ChatMessage{{Role: "system", Content: systemPrompt}}
+	// Skip the command system prompt, keeping the requests and commands
+	messages = append(messages, conversation[1:]...)
+	messages = append(messages, api.ChatMessage{
+		Role:    "user",
+		Content: "Explain this command in detail and why it's relevant to my situation",
+	})
+
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+	}
+	params := generationParams(cmd, cfg, nil)
+	params(provider, req)
+
+	resp, _, _, err := completeWithFallback(cfg, provider, req, params)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response received from model")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// requestCommand asks the command model for a command and returns it
+// without any code fence the model may have added.
+func requestCommand(cmd *cobra.Command, cfg *config.Config, messages []api.ChatMessage) (string, error) {
+	provider, model, err := resolveCommandModel(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+	}
+	params := generationParams(cmd, cfg, nil)
+	params(provider, req)
+
+	resp, _, _, err := completeWithFallback(cfg, provider, req, params)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response received from model")
+	}
+	return cleanCommand(resp.Choices[0].Message.Content), nil
+}
+
+// commandAlternative is one of several candidate commands.
+type commandAlternative struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation"`
+}
+
+// requestAlternatives asks the model for n distinct commands for the
+// description, each with a short explanation.
+func requestAlternatives(cmd *cobra.Command, cfg *config.Config, environment, description string, n int) ([]commandAlternative, error) {
+	systemPrompt := fmt.Sprintf(`Suggest %d distinct shell commands that each accomplish the user's task, from the most to the least common approach. Reply with only a JSON array of objects with a "command" field holding the raw command and an "explanation" field with one short sentence on how it differs from the others. No markdown, no other text.`, n)
+	if environment != "" {
+		systemPrompt += "\n\n" + environment
+	}
+	// A chosen command system prompt still applies. It goes first, so the
+	// reply format above wins over what it says about the reply
+	custom, err := commandSystemPrompt(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if custom != defaultCommandSystemPrompt {
+		systemPrompt = custom + "\n\n" + systemPrompt
+	}
+
+	provider, model, err := resolveCommandModel(cfg)
+	if err != nil {
+		return nil, err
+	}
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: buildMessages(systemPrompt, description),
+	}
+	params := generationParams(cmd, cfg, nil)
+	params(provider, req)
+
+	resp, _, _, err := completeWithFallback(cfg, provider, req, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response received from model")
+	}
+
+	var candidates []commandAlternative
+	content := resp.Choices[0].Message.Content
+	if err := json.Unmarshal([]byte(extractJSON(content)), &candidates); err != nil {
+		return nil, fmt.Errorf("the model didn't return a list of commands: %s", truncateRunes(strings.Join(strings.Fields(content), " "), 200))
+	}
+
+	var alternatives []commandAlternative
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		c.Command = cleanCommand(c.Command)
+		if c.Command == "" || seen[c.Command] {
+			continue
 		}
-		return api.NewGroqClient(cfg.GroqAPIKey).CreateChatCompletion(req)
-	case "openai":
-		if cfg.OpenAIAPIKey == "" {
-			return nil, fmt.Errorf("openai API key not set")
+		seen[c.Command] = true
+		alternatives = append(alternatives, c)
+		if len(alternatives) == n {
+			break
 		}
-		return api.NewOpenAIClient(cfg.OpenAIAPIKey).CreateChatCompletion(req)
-	case "gemini":
-		if cfg.GeminiAPIKey == "" {
-			return nil, fmt.Errorf("gemini API key not set")
+	}
+	if len(alternatives) == 0 {
+		return nil, fmt.Errorf("the model returned no commands")
+	}
+	return alternatives, nil
+}
+
+// pickAlternative lets the user choose one of n suggested commands.
+func pickAlternative(cmd *cobra.Command, cfg *config.Config, environment, description string, n int) (string, error) {
+	alternatives, err := requestAlternatives(cmd, cfg, environment, description, n)
+	if err != nil {
+		return "", err
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0].Command, nil
+	}
+
+	prompt := promptui.Select{
+		Label: "Pick a command",
+		Items: alternatives,
+		Size:  min(len(alternatives), 10),
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ . }}",
+			Active:   "\U0001F449 {{ .Command | cyan }}", // 👉
+			Inactive: "  {{ .Command | white }}",
+			Selected: "\U00002705 {{ .Command | green }}", // ✅
+			Details:  "{{ .Explanation | faint }}",
+		},
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return alternatives[i].Command, nil
+}
+
+// cleanCommand trims whitespace and a surrounding markdown code fence.
+func cleanCommand(command string) string {
+	command = strings.TrimSpace(command)
+	if strings.HasPrefix(command, "```") && strings.HasSuffix(command, "```") {
+		command = strings.TrimSuffix(command, "```")
+		// Drop the opening fence along with its language tag
+		if _, rest, ok := strings.Cut(command, "\n"); ok {
+			command = rest
+		} else {
+			command = strings.TrimPrefix(command, "```")
 		}
-		return api.NewGeminiClient(cfg.GeminiAPIKey).CreateChatCompletion(req)
-	case "ollama":
-		return api.NewOllamaClient(cfg.OllamaHost).CreateChatCompletion(req)
-	default:
-		return nil, fmt.Errorf("model '%s' not supported", cfg.Model)
 	}
+	return strings.TrimSpace(command)
+}
+
+// runInShell runs command with the user's shell, attached to the terminal so
+// interactive programs work, and returns its exit status along with the end
+// of what it wrote to stderr.
+func runInShell(command string) (int, string, error) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+
+	stderr := &tailWriter{max: maxCapturedStderr}
+	c := exec.Command(shell, "-c", command)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = io.MultiWriter(os.Stderr, stderr)
+	err := c.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), stderr.String(), nil
+	}
+	if err != nil {
+		return -1, "", err
+	}
+	return 0, stderr.String(), nil
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	max int
+	buf []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+func (t *tailWriter) String() string {
+	return string(t.buf)
+}
+
+// copyToClipboard copies text to the clipboard with an OSC52 escape
+// sequence, which works over SSH and inside tmux and screen.
+func copyToClipboard(text string) {
+	seq := osc52.New(text)
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(term, "screen"):
+		seq = seq.Screen()
+	}
+	seq.WriteTo(os.Stderr)
 }
 
 func init() {
+	cmdCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Model to use (default cmd_model, then the default model)")
+	cmdCmd.Flags().StringVarP(&systemFlag, "system", "s", "", "Use a system prompt by title instead of the built-in one")
+	cmdCmd.Flags().BoolVarP(&cmdPrintFlag, "print", "p", false, "Print the command without prompting")
+	cmdCmd.Flags().StringVar(&cmdShellFlag, "shell", "", "Shell the command is for (default from $SHELL)")
+	cmdCmd.Flags().IntVarP(&cmdAlternativesFlag, "alternatives", "n", 1, "Number of alternative commands to choose from")
+	cmdCmd.Flags().IntVar(&cmdMaxFixesFlag, "max-fixes", 3, "How many times a failed command may be sent back to the model for a fix")
+	cmdCmd.Flags().BoolVar(&cmdNoContextFlag, "no-context", false, "Don't tell the model about your system, directory or installed tools")
 	rootCmd.AddCommand(cmdCmd)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// maxContextEntries bounds how many files of the current directory are
+	// listed
+	maxContextEntries = 30
+	contextTimeout    = 2 * time.Second
+)
+
+// contextTools are the programs whose presence changes which command is
+// best: package managers, GNU or BSD variants and common developer tools.
+var contextTools = []string{
+	"apt", "dnf", "yum", "pacman", "zypper", "apk", "brew", "port", "nix",
+	"systemctl", "launchctl", "gsed", "gawk", "gfind",
+	"git", "docker", "podman", "kubectl", "python3", "node", "npm", "go", "cargo",
+	"rg", "fd", "jq", "fzf", "curl", "wget",
+}
+
+// commandContext describes where the command will run, so the model picks
+// the right syntax, flags and tools.
+func commandContext(shell string) string {
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+	if shell == "" || shell == "." {
+		shell = "sh"
+	}
+
+	lines := []string{fmt.Sprintf("The command will run in %s on %s.", shell, operatingSystem())}
+
+	if dir, err := os.Getwd(); err == nil {
+		line := fmt.Sprintf("The current directory is %s", dir)
+		if listing := directorySummary(dir); listing != "" {
+			line += ", containing: " + listing
+		}
+		lines = append(lines, line+".")
+	}
+
+	if status := gitSummary(); status != "" {
+		lines = append(lines, status)
+	}
+
+	var installed []string
+	for _, tool := range contextTools {
+		if _, err := exec.LookPath(tool); err == nil {
+			installed = append(installed, tool)
+		}
+	}
+	if len(installed) > 0 {
+		lines = append(lines, "Installed tools: "+strings.Join(installed, ", ")+".")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// operatingSystem names the OS and, where it can be found, the Linux
+// distribution or macOS version.
+func operatingSystem() string {
+	name := runtime.GOOS
+	switch runtime.GOOS {
+	case "linux":
+		if distro := osRelease("/etc/os-release"); distro != "" {
+			name = "Linux (" + distro + ")"
+		} else {
+			name = "Linux"
+		}
+	case "darwin":
+		name = "macOS"
+		if version := commandOutput("sw_vers", "-productVersion"); version != "" {
+			name += " " + version
+		}
+	}
+	return fmt.Sprintf("%s, %s", name, runtime.GOARCH)
+}
+
+// osRelease returns the PRETTY_NAME, or else the NAME, of an os-release
+// file.
+func osRelease(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			values[key] = strings.Trim(value, `"'`)
+		}
+	}
+	if values["PRETTY_NAME"] != "" {
+		return values["PRETTY_NAME"]
+	}
+	return values["NAME"]
+}
+
+// directorySummary lists the entries of dir, directories with a trailing
+// slash.
+func directorySummary(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var names []string
+	for i, entry := range entries {
+		if i == maxContextEntries {
+			names = append(names, fmt.Sprintf("and %d more", len(entries)-maxContextEntries))
+			break
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "nothing (it is empty)"
+	}
+	return strings.Join(names, ", ")
+}
+
+// gitSummary describes the branch and changes of the repository the current
+// directory is in, if any.
+func gitSummary() string {
+	branch := commandOutput("git", "rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "" {
+		return ""
+	}
+
+	changed := 0
+	if status := commandOutput("git", "status", "--porcelain"); status != "" {
+		changed = len(strings.Split(status, "\n"))
+	}
+	summary := fmt.Sprintf("It is in a git repository on branch %s", branch)
+	if changed == 0 {
+		return summary + " with no uncommitted changes."
+	}
+	return fmt.Sprintf("%s with %d changed files.", summary, changed)
+}
+
+// commandOutput runs a command and returns its trimmed output, or nothing
+// if it fails or takes too long.
+func commandOutput(name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit  int
+	historyRun    bool
+	historyExport string
+)
+
+// historyEntry is a command suggested by 'suggest cmd' and what became of
+// it.
+type historyEntry struct {
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+	Command     string    `json:"command"`
+	Ran         bool      `json:"ran"`
+	ExitCode    *int      `json:"exit_code,omitempty"`
+	Cwd         string    `json:"cwd"`
+}
+
+var cmdHistoryCmd = &cobra.Command{
+	Use:   "history [search terms]",
+	Short: "Search, re-run and export past 'suggest cmd' commands",
+	Long: `List the commands 'suggest cmd' suggested, newest last, with whether they ran
+and their exit status. Search terms filter on the description and the
+command, ignoring case.
+
+The history is kept in ~/.config/suggest/cmd_history.jsonl.
+
+Example:
+  suggest cmd history
+  suggest cmd history docker prune
+  suggest cmd history --run ffmpeg
+  suggest cmd history --export sh > commands.sh
+  suggest cmd history --export csv -n 0 > history.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := loadCommandHistory()
+		if err != nil {
+			fmt.Printf("Error reading history: %v\n", err)
+			return
+		}
+
+		entries = filterHistory(entries, args)
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[len(entries)-historyLimit:]
+		}
+		if len(entries) == 0 {
+			if historyExport == "" {
+				fmt.Println("No matching commands in the history")
+			}
+			return
+		}
+
+		switch {
+		case historyExport != "":
+			if err := exportHistory(entries, historyExport); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		case historyRun:
+			rerunFromHistory(entries)
+		default:
+			for _, e := range entries {
+				fmt.Println(formatHistoryEntry(e))
+			}
+		}
+	},
+}
+
+func formatHistoryEntry(e historyEntry) string {
+	status := faint("not run")
+	if e.Ran && e.ExitCode != nil {
+		if *e.ExitCode == 0 {
+			status = green("exit 0")
+		} else {
+			status = red(fmt.Sprintf("exit %d", *e.ExitCode))
+		}
+	}
+	return fmt.Sprintf("%s  %s  %s\n    %s",
+		faint(e.Time.Local().Format("2006-01-02 15:04")), padVisible(status, 8), cyan(e.Command),
+		faint(fmt.Sprintf("%s · %s", e.Description, e.Cwd)))
+}
+
+// rerunFromHistory lets the user pick one of the entries and runs its
+// command again in the current directory.
+func rerunFromHistory(entries []historyEntry) {
+	// Newest first, so the most likely pick is at the top
+	items := make([]historyEntry, len(entries))
+	for i, e := range entries {
+		items[len(entries)-1-i] = e
+	}
+
+	prompt := promptui.Select{
+		Label: "Pick a command to run",
+		Items: items,
+		Size:  min(len(items), 10),
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ . }}",
+			Active:   "\U0001F449 {{ .Command | cyan }}", // 👉
+			Inactive: "  {{ .Command | white }}",
+			Selected: "\U00002705 {{ .Command | green }}", // ✅
+			Details:  "{{ .Description | faint }}",
+		},
+		Searcher: func(input string, index int) bool {
+			return historyMatches(items[index], strings.Fields(input))
+		},
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return
+	}
+	entry := items[i]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	if !confirmCommandRisk(cfg, entry.Command, false) {
+		fmt.Println("Command not run")
+		return
+	}
+
+	code, _, err := runInShell(entry.Command)
+	if err != nil {
+		fmt.Printf("Error executing command: %v\n", err)
+		return
+	}
+	recordCommand(entry.Description, entry.Command, true, code)
+	if code == 0 {
+		fmt.Printf("\n%s\n", green("Exit status 0"))
+	} else {
+		fmt.Printf("\n%s\n", red(fmt.Sprintf("Exit status %d", code)))
+	}
+}
+
+// exportHistory writes the entries to stdout as JSON lines, CSV or a shell
+// script.
+func exportHistory(entries []historyEntry, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"time", "description", "command", "ran", "exit_code", "cwd"})
+		for _, e := range entries {
+			exitCode := ""
+			if e.ExitCode != nil {
+				exitCode = strconv.Itoa(*e.ExitCode)
+			}
+			w.Write([]string{e.Time.Format(time.RFC3339), e.Description, e.Command, strconv.FormatBool(e.Ran), exitCode, e.Cwd})
+		}
+		w.Flush()
+		return w.Error()
+
+	case "sh":
+		fmt.Println("#!/bin/sh")
+		seen := make(map[string]bool)
+		for _, e := range entries {
+			if seen[e.Command] {
+				continue
+			}
+			seen[e.Command] = true
+			fmt.Printf("\n# %s\n%s\n", strings.ReplaceAll(e.Description, "\n", " "), e.Command)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format '%s'. Use 'json', 'csv', or 'sh'", format)
+}
+
+func filterHistory(entries []historyEntry, terms []string) []historyEntry {
+	if len(terms) == 0 {
+		return entries
+	}
+	var matches []historyEntry
+	for _, e := range entries {
+		if historyMatches(e, terms) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// historyMatches reports whether every term appears in the entry's
+// description or command.
+func historyMatches(e historyEntry, terms []string) bool {
+	text := strings.ToLower(e.Description + "\n" + e.Command)
+	for _, term := range terms {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+func commandHistoryPath() (string, error) {
+	configPath, err := config.ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "cmd_history.jsonl"), nil
+}
+
+// loadCommandHistory returns every entry of the history, oldest first.
+func loadCommandHistory() ([]historyEntry, error) {
+	path, err := commandHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Skip malformed lines
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// recordCommand appends a command to the history. Failing to record is
+// not worth interrupting the user for, so errors are only reported.
+func recordCommand(description, command string, ran bool, exitCode int) {
+	entry := historyEntry{
+		Time:        time.Now().UTC(),
+		Description: description,
+		Command:     command,
+		Ran:         ran,
+	}
+	if ran {
+		entry.ExitCode = &exitCode
+	}
+	entry.Cwd, _ = os.Getwd()
+
+	if err := appendCommandHistory(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", yellow("Could not save the command history:"), err)
+	}
+}
+
+func appendCommandHistory(entry historyEntry) error {
+	path, err := commandHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func init() {
+	cmdHistoryCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Show at most this many of the newest matches (0 for all)")
+	cmdHistoryCmd.Flags().BoolVar(&historyRun, "run", false, "Pick one of the matches and run it again")
+	cmdHistoryCmd.Flags().StringVar(&historyExport, "export", "", "Write the matches to stdout as json, csv, or sh")
+	cmdCmd.AddCommand(cmdHistoryCmd)
+}
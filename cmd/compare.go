@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	compareModels []string
+	compareFormat string
+)
+
+// compareResult is the answer of a single model in a comparison.
+type compareResult struct {
+	Model            string          `json:"model"`
+	Provider         config.Provider `json:"provider,omitempty"`
+	Response         string          `json:"response,omitempty"`
+	LatencyMs        int64           `json:"latency_ms"`
+	PromptTokens     int             `json:"prompt_tokens"`
+	CompletionTokens int             `json:"completion_tokens"`
+	TotalTokens      int             `json:"total_tokens"`
+	Error            string          `json:"error,omitempty"`
+}
+
+var compareCmd = &cobra.Command{
+	Use:   "compare [message]",
+	Short: "Send the same prompt to several models and compare the answers",
+	Long: `Send the same prompt to several models concurrently and show each answer
+with its latency and token usage.
+
+The system prompt and template flags work the same way as for 'suggest'.
+Answers are shown side by side in terminal columns, or as a markdown or
+JSON report with --format.
+
+Example:
+  suggest compare -m gpt-4o -m llama-3.3-70b-versatile -m gemini-1.5-pro "Explain monads"
+  suggest compare -m gpt-4o,groq/llama-3.3-70b-versatile -s "Programming Assistant" "Write a quicksort in Go"
+  suggest compare -m gpt-4o -m gemini-1.5-pro -t "Code Function" --vars "language=Go,task=reverses a string"
+  cat main.go | suggest compare -m gpt-4o -m llama-3.3-70b-versatile --format markdown "Review this code" > review.md`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(compareModels) < 2 {
+			fmt.Println("Please provide at least two models with -m")
+			return
+		}
+		if compareFormat != "columns" && compareFormat != "markdown" && compareFormat != "json" {
+			fmt.Printf("Unknown format '%s'. Use 'columns', 'markdown', or 'json'\n", compareFormat)
+			return
+		}
+
+		message, err := readMessage(args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if strings.TrimSpace(message) == "" && templateFlag == "" {
+			fmt.Println("Please provide a message via arguments or pipe content. Use --help for more information.")
+			return
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		if templateFlag != "" {
+			message, err = applyTemplate(cfg, templateFlag, varsFlag)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		systemPrompt, err := resolveSystemPrompt(cfg, systemFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		messages := buildMessages(systemPrompt, message)
+
+		if compareFormat == "columns" {
+			fmt.Fprintf(os.Stderr, "Asking %d models...\n", len(compareModels))
+		}
+
+		resolved := resolveModels(cfg, compareModels...)
+		results := make([]compareResult, len(compareModels))
+		var wg sync.WaitGroup
+		for i, name := range compareModels {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				results[i] = runComparison(cmd, cfg, resolved, name, messages)
+			}(i, name)
+		}
+		wg.Wait()
+
+		switch compareFormat {
+		case "json":
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				fmt.Printf("Error encoding results: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+		case "markdown":
+			fmt.Print(formatCompareMarkdown(message, results))
+		default:
+			printCompareColumns(results)
+		}
+	},
+}
+
+// runComparison asks a single model and records its answer, latency and
+// token usage.
+func runComparison(cmd *cobra.Command, cfg *config.Config, resolved resolvedModels, name string, messages []api.ChatMessage) compareResult {
+	result := compareResult{Model: name}
+
+	provider, model, err := resolved.get(name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Provider = provider
+	result.Model = model
+
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+	}
+	applyGenerationParams(cmd, cfg, provider, req, api.Float(0.7))
+
+	start := time.Now()
+	resp, err := getResponse(provider, cfg, req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(resp.Choices) == 0 {
+		result.Error = "no response received from model"
+		return result
+	}
+
+	result.Response = resp.Choices[0].Message.Content
+	result.PromptTokens = resp.Usage.PromptTokens
+	result.CompletionTokens = resp.Usage.CompletionTokens
+	result.TotalTokens = resp.Usage.TotalTokens
+	return result
+}
+
+func (r compareResult) summary() string {
+	summary := fmt.Sprintf("%.2fs", float64(r.LatencyMs)/1000)
+	if r.TotalTokens > 0 {
+		summary += fmt.Sprintf(" · %d in / %d out tokens", r.PromptTokens, r.CompletionTokens)
+	}
+	return summary
+}
+
+func formatCompareMarkdown(prompt string, results []compareResult) string {
+	var b strings.Builder
+	b.WriteString("# Model comparison\n\n")
+	b.WriteString("## Prompt\n\n")
+	b.WriteString(prompt + "\n\n")
+
+	b.WriteString("| Model | Provider | Latency | Prompt tokens | Completion tokens | Status |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, r := range results {
+		status := "ok"
+		if r.Error != "" {
+			status = "error"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %.2fs | %d | %d | %s |\n",
+			r.Model, r.Provider, float64(r.LatencyMs)/1000, r.PromptTokens, r.CompletionTokens, status)
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Model)
+		if r.Error != "" {
+			fmt.Fprintf(&b, "**Error:** %s\n", r.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "_%s_\n\n%s\n", r.summary(), r.Response)
+	}
+	return b.String()
+}
+
+// printCompareColumns prints the answers side by side, falling back to one
+// after another when the terminal is too narrow for the columns.
+func printCompareColumns(results []compareResult) {
+	width := 120
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		width = w
+	}
+
+	const separator = " │ "
+	columnWidth := (width - len(separator)*(len(results)-1)) / len(results)
+
+	if columnWidth < 30 {
+		for _, r := range results {
+			fmt.Printf("\n%s %s\n%s\n\n", cyan(r.Model), faint(r.summary()), strings.Repeat("─", min(width, 80)))
+			if r.Error != "" {
+				fmt.Printf("%s %s\n", red("Error:"), r.Error)
+				continue
+			}
+			fmt.Print(renderWithGlamour(r.Response))
+		}
+		return
+	}
+
+	columns := make([][]string, len(results))
+	height := 0
+	for i, r := range results {
+		lines := []string{
+			cyan(truncateRunes(r.Model, columnWidth)),
+			faint(truncateRunes(r.summary(), columnWidth)),
+			strings.Repeat("─", columnWidth),
+		}
+		body := r.Response
+		if r.Error != "" {
+			body = "Error: " + r.Error
+		}
+		wrapped := wrap.String(wordwrap.String(body, columnWidth), columnWidth)
+		lines = append(lines, strings.Split(wrapped, "\n")...)
+		columns[i] = lines
+		height = max(height, len(lines))
+	}
+
+	fmt.Println()
+	for row := 0; row < height; row++ {
+		cells := make([]string, len(columns))
+		for i, lines := range columns {
+			cell := ""
+			if row < len(lines) {
+				cell = lines[row]
+			}
+			cells[i] = padVisible(cell, columnWidth)
+		}
+		fmt.Println(strings.TrimRight(strings.Join(cells, separator), " "))
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// padVisible pads s with spaces to width, ignoring ANSI color codes.
+func padVisible(s string, width int) string {
+	visible := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			visible++
+		}
+	}
+	if visible >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-visible)
+}
+
+func init() {
+	compareCmd.Flags().StringSliceVarP(&compareModels, "model", "m", nil, "Model to compare (repeat or comma-separate for several)")
+	compareCmd.Flags().StringVarP(&templateFlag, "template", "t", "", "Use a template (format: template-name)")
+	compareCmd.Flags().StringVar(&varsFlag, "vars", "", "Variables for the template (format: key1=value1,key2=value2)")
+	compareCmd.Flags().StringVarP(&systemFlag, "system", "s", "", "Use a specific system prompt by title")
+	compareCmd.Flags().StringVarP(&compareFormat, "format", "f", "columns", "Output format: columns, markdown, or json")
+	addGenerationFlags(compareCmd)
+	rootCmd.AddCommand(compareCmd)
+}
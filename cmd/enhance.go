@@ -45,7 +45,7 @@ func enhancePrompt(prompt string, cfg *config.Config) (string, error) {
 	req := &api.ChatCompletionRequest{
 		Model:       "llama-3.3-70b-versatile",
 		Messages:    messages,
-		Temperature: 0.7,
+		Temperature: api.Float(0.7),
 		Stream:      false,
 	}
 
@@ -88,11 +88,13 @@ var enhanceCmd = &cobra.Command{
 	Use:   "enhance [message]",
 	Short: "Enhance a coding-related prompt before processing",
 	Long: `Enhance a coding-related prompt by adding more specificity, clarity, and structure.
-The enhanced prompt will be processed by Groq's llama-3.3-70b-versatile model before being sent to your default model.
+The enhanced prompt will be processed by Groq's llama-3.3-70b-versatile model before being sent to your default model,
+or to the model given with --model.
 
 Example:
   suggest enhance "How do I use generics?"
-  suggest enhance "What are design patterns?"`,
+  suggest enhance "What are design patterns?"
+  suggest enhance -m gpt-4o -s "Programming Assistant" "How do I profile Go code?"`,
 	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
@@ -121,27 +123,30 @@ Example:
 		fmt.Printf("\nEnhanced prompt:\n%s\n\nProcessing enhanced prompt...\n\n", 
 			renderWithGlamour(enhancedPrompt))
 
-		// Now process the enhanced prompt with the default model
-		messages := []api.ChatMessage{}
-		if cfg.SystemPrompt != "" {
-			messages = append(messages, api.ChatMessage{
-				Role:    "system",
-				Content: cfg.SystemPrompt,
-			})
+		// Now process the enhanced prompt with the selected model
+		systemPrompt, err := resolveSystemPrompt(cfg, systemFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
+		messages := buildMessages(systemPrompt, enhancedPrompt)
 
-		messages = append(messages, api.ChatMessage{
-			Role:    "user",
-			Content: enhancedPrompt,
-		})
+		model := cfg.Model
+		if modelFlag != "" {
+			model = modelFlag
+		}
+		provider, model, err := config.ResolveModel(model, cfg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 
 		req := &api.ChatCompletionRequest{
-			Model:       cfg.Model,
-			Messages:    messages,
-			Temperature: 0.7,
+			Model:    model,
+			Messages: messages,
 		}
+		applyGenerationParams(cmd, cfg, provider, req, api.Float(0.7))
 
-		provider := config.DetermineModelProvider(cfg.Model, cfg)
 		resp, apiErr := getResponse(provider, cfg, req)
 		if apiErr != nil {
 			fmt.Printf("Error: %v\n", apiErr)
@@ -156,5 +161,7 @@ Example:
 }
 
 func init() {
+	enhanceCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Specify the model to use")
+	enhanceCmd.Flags().StringVarP(&systemFlag, "system", "s", "", "Use a specific system prompt by title")
 	rootCmd.AddCommand(enhanceCmd)
 } 
\ No newline at end of file
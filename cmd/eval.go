@@ -0,0 +1,552 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/jsonschema"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	evalModels      []string
+	evalConcurrency int
+	evalReport      string
+)
+
+const evalJudgePrompt = `You grade the answer of an AI assistant against a rubric. Reply with only a JSON object of the form {"pass": true, "reason": "one sentence"}, with pass set to false unless the answer fully meets the rubric.`
+
+// evalSuite is a suite file. Its template, system prompt, variables and
+// assertions apply to every case that doesn't set its own.
+type evalSuite struct {
+	Name        string            `yaml:"name"`
+	Models      []string          `yaml:"models"`
+	System      string            `yaml:"system"`
+	Template    string            `yaml:"template"`
+	Vars        map[string]string `yaml:"vars"`
+	JudgeModel  string            `yaml:"judge_model"`
+	Concurrency int               `yaml:"concurrency"`
+	Assert      []evalAssertion   `yaml:"assert"`
+	Cases       []evalCase        `yaml:"cases"`
+}
+
+type evalCase struct {
+	Name     string            `yaml:"name"`
+	Prompt   string            `yaml:"prompt"`
+	System   string            `yaml:"system"`
+	Template string            `yaml:"template"`
+	Vars     map[string]string `yaml:"vars"`
+	Assert   []evalAssertion   `yaml:"assert"`
+}
+
+// evalAssertion is a check of an answer. Exactly one field is set.
+type evalAssertion struct {
+	Contains    string      `yaml:"contains"`
+	NotContains string      `yaml:"not_contains"`
+	Regex       string      `yaml:"regex"`
+	JSONSchema  interface{} `yaml:"json_schema"`
+	MaxLength   int         `yaml:"max_length"`
+	Judge       string      `yaml:"judge"`
+}
+
+// evalCheck is the outcome of an assertion.
+type evalCheck struct {
+	Assertion string `json:"assertion"`
+	Pass      bool   `json:"pass"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// evalResult is the outcome of a case run against a model.
+type evalResult struct {
+	Case       string          `json:"case"`
+	Model      string          `json:"model"`
+	Provider   config.Provider `json:"provider,omitempty"`
+	Pass       bool            `json:"pass"`
+	Response   string          `json:"response,omitempty"`
+	LatencyMs  int64           `json:"latency_ms"`
+	Usage      batchUsage      `json:"usage"`
+	Error      string          `json:"error,omitempty"`
+	Assertions []evalCheck     `json:"assertions"`
+}
+
+type evalReportFile struct {
+	Suite   string       `json:"suite"`
+	RanAt   time.Time    `json:"ran_at"`
+	Passed  int          `json:"passed"`
+	Failed  int          `json:"failed"`
+	Results []evalResult `json:"results"`
+}
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <suite.yml>",
+	Short: "Run a suite of prompts and check the answers against assertions",
+	Long: `Run every case of a suite against one or more models and check the answers,
+so changes to templates, system prompts or models can be regression-tested.
+
+A suite names the models, the system prompt and template titles and the
+assertions shared by its cases. Cases can override any of them:
+
+  name: Code helpers
+  models: [gpt-4o-mini, groq/llama-3.3-70b-versatile]
+  system: Programming Assistant
+  template: Code Function
+  judge_model: gpt-4o
+  assert:
+    - max_length: 2000
+  cases:
+    - name: reverse
+      vars: {language: Go, task: reverses a string}
+      assert:
+        - contains: "func"
+        - regex: "(?i)rune"
+        - judge: Handles multi-byte characters correctly
+    - name: json
+      prompt: Return a JSON object with a "name" string and an "age" number.
+      assert:
+        - json_schema:
+            type: object
+            required: [name, age]
+            properties:
+              name: {type: string}
+              age: {type: number}
+
+Assertions:
+  contains      The answer contains the text
+  not_contains  The answer doesn't contain the text
+  regex         The answer matches the regular expression
+  json_schema   The answer is JSON valid against the schema (a common subset)
+  max_length    The answer has at most this many characters
+  judge         A judge model finds the answer meets the rubric
+
+A case's prompt is appended to its template. A case with a prompt but no
+template of its own doesn't use the suite's template.
+
+The judge is judge_model, or the configured model. Cases run concurrently.
+A pass/fail matrix is printed and the full results are written as a JSON
+report. The command exits with status 1 when any case fails.
+
+Example:
+  suggest eval prompts.eval.yml
+  suggest eval prompts.eval.yml -m gpt-4o -m gemini-1.5-pro --report report.json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		suite, err := loadEvalSuite(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		models := suite.Models
+		if len(evalModels) > 0 {
+			models = evalModels
+		}
+		if len(models) == 0 {
+			models = []string{cfg.Model}
+		}
+
+		concurrency := suite.Concurrency
+		if cmd.Flags().Changed("concurrency") || concurrency < 1 {
+			concurrency = evalConcurrency
+		}
+		if concurrency < 1 {
+			concurrency = 1
+		}
+
+		reportPath := evalReport
+		if reportPath == "" {
+			reportPath = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ".report.json"
+		}
+
+		total := len(suite.Cases) * len(models)
+		fmt.Fprintf(os.Stderr, "Running %d cases against %d models...\n", len(suite.Cases), len(models))
+
+		if suite.JudgeModel == "" {
+			suite.JudgeModel = cfg.Model
+		}
+		resolved := resolveModels(cfg, append([]string{suite.JudgeModel}, models...)...)
+
+		results := make([]evalResult, total)
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i, c := range suite.Cases {
+			for j, model := range models {
+				wg.Add(1)
+				go func(index int, c evalCase, model string) {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					results[index] = runEvalCase(cmd, cfg, resolved, suite, c, model)
+				}(i*len(models)+j, c, model)
+			}
+		}
+		wg.Wait()
+
+		report := evalReportFile{Suite: suite.Name, RanAt: time.Now(), Results: results}
+		for _, r := range results {
+			if r.Pass {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+		}
+
+		printEvalMatrix(suite, models, results)
+
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(reportPath, data, 0644)
+		}
+		if err != nil {
+			fmt.Printf("Error writing report: %v\n", err)
+		} else {
+			fmt.Printf("Report written to %s\n", reportPath)
+		}
+
+		if report.Failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadEvalSuite reads and checks a suite file, naming unnamed cases by
+// their position.
+func loadEvalSuite(path string) (*evalSuite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite evalSuite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("%s has no cases", path)
+	}
+	if suite.Name == "" {
+		suite.Name = filepath.Base(path)
+	}
+
+	for i := range suite.Cases {
+		c := &suite.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case %d", i+1)
+		}
+		for _, a := range suite.assertions(*c) {
+			if err := a.check(); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Name, err)
+			}
+		}
+	}
+	return &suite, nil
+}
+
+// assertions returns the suite's assertions followed by the case's own.
+func (s *evalSuite) assertions(c evalCase) []evalAssertion {
+	all := make([]evalAssertion, 0, len(s.Assert)+len(c.Assert))
+	all = append(all, s.Assert...)
+	return append(all, c.Assert...)
+}
+
+// check makes sure exactly one kind of assertion is set and that regular
+// expressions and schemas are usable.
+func (a evalAssertion) check() error {
+	set := 0
+	for _, ok := range []bool{a.Contains != "", a.NotContains != "", a.Regex != "", a.JSONSchema != nil, a.MaxLength > 0, a.Judge != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("each assertion needs exactly one of contains, not_contains, regex, json_schema, max_length or judge")
+	}
+	if a.Regex != "" {
+		if _, err := regexp.Compile(a.Regex); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", a.Regex, err)
+		}
+	}
+	if a.JSONSchema != nil {
+		if _, ok := yamlToJSON(a.JSONSchema).(map[string]interface{}); !ok {
+			return fmt.Errorf("json_schema must be a mapping")
+		}
+	}
+	return nil
+}
+
+func (a evalAssertion) String() string {
+	switch {
+	case a.Contains != "":
+		return fmt.Sprintf("contains %q", a.Contains)
+	case a.NotContains != "":
+		return fmt.Sprintf("not_contains %q", a.NotContains)
+	case a.Regex != "":
+		return fmt.Sprintf("regex %q", a.Regex)
+	case a.JSONSchema != nil:
+		return "json_schema"
+	case a.MaxLength > 0:
+		return fmt.Sprintf("max_length %d", a.MaxLength)
+	}
+	return fmt.Sprintf("judge %q", a.Judge)
+}
+
+// runEvalCase asks a model and checks the answer against the suite's and
+// the case's assertions.
+func runEvalCase(cmd *cobra.Command, cfg *config.Config, resolved resolvedModels, suite *evalSuite, c evalCase, name string) evalResult {
+	result := evalResult{Case: c.Name, Model: name, Assertions: []evalCheck{}}
+
+	template := suite.Template
+	if c.Template != "" || c.Prompt != "" {
+		// A case that brings its own prompt only uses a template it names
+		template = c.Template
+	}
+	vars := make(map[string]string)
+	for k, v := range suite.Vars {
+		vars[k] = v
+	}
+	for k, v := range c.Vars {
+		vars[k] = v
+	}
+	message, err := composeMessage(cfg, template, vars, c.Prompt)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	systemTitle := suite.System
+	if c.System != "" {
+		systemTitle = c.System
+	}
+	systemPrompt, err := resolveSystemPrompt(cfg, systemTitle)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	provider, model, err := resolved.get(name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Provider = provider
+	result.Model = model
+
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: buildMessages(systemPrompt, message),
+	}
+	applyGenerationParams(cmd, cfg, provider, req, api.Float(0.7))
+
+	start := time.Now()
+	resp, err := getResponse(provider, cfg, req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(resp.Choices) == 0 {
+		result.Error = "no response received from model"
+		return result
+	}
+	result.Response = resp.Choices[0].Message.Content
+	result.Usage = batchUsage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+
+	result.Pass = true
+	for _, a := range suite.assertions(c) {
+		check := evalCheck{Assertion: a.String()}
+		check.Pass, check.Detail = runEvalAssertion(cfg, resolved, suite, a, message, result.Response)
+		result.Pass = result.Pass && check.Pass
+		result.Assertions = append(result.Assertions, check)
+	}
+	return result
+}
+
+func runEvalAssertion(cfg *config.Config, resolved resolvedModels, suite *evalSuite, a evalAssertion, prompt, answer string) (bool, string) {
+	switch {
+	case a.Contains != "":
+		return strings.Contains(answer, a.Contains), ""
+
+	case a.NotContains != "":
+		return !strings.Contains(answer, a.NotContains), ""
+
+	case a.Regex != "":
+		return regexp.MustCompile(a.Regex).MatchString(answer), ""
+
+	case a.JSONSchema != nil:
+		var value interface{}
+		if err := json.Unmarshal([]byte(extractJSON(answer)), &value); err != nil {
+			return false, "answer is not JSON: " + err.Error()
+		}
+		schema := yamlToJSON(a.JSONSchema).(map[string]interface{})
+		if errs := jsonschema.Validate(schema, value); len(errs) > 0 {
+			return false, strings.Join(errs, "; ")
+		}
+		return true, ""
+
+	case a.MaxLength > 0:
+		length := utf8.RuneCountInString(answer)
+		return length <= a.MaxLength, fmt.Sprintf("%d characters", length)
+	}
+
+	return judgeAnswer(cfg, resolved, suite.JudgeModel, a.Judge, prompt, answer)
+}
+
+// judgeAnswer asks the judge model whether answer meets the rubric.
+func judgeAnswer(cfg *config.Config, resolved resolvedModels, judgeModel, rubric, prompt, answer string) (bool, string) {
+	provider, model, err := resolved.get(judgeModel)
+	if err != nil {
+		return false, "judge: " + err.Error()
+	}
+
+	req := &api.ChatCompletionRequest{
+		Model: model,
+		Messages: buildMessages(evalJudgePrompt, fmt.Sprintf(
+			"Prompt:\n%s\n\nAnswer:\n%s\n\nRubric:\n%s", prompt, answer, rubric)),
+		Temperature: api.Float(0),
+	}
+	resp, err := getResponse(provider, cfg, req)
+	if err != nil {
+		return false, "judge: " + err.Error()
+	}
+	if len(resp.Choices) == 0 {
+		return false, "judge: no response received from model"
+	}
+
+	var verdict struct {
+		Pass   bool   `json:"pass"`
+		Reason string `json:"reason"`
+	}
+	content := resp.Choices[0].Message.Content
+	if err := json.Unmarshal([]byte(extractJSON(content)), &verdict); err != nil {
+		return false, "judge: unexpected verdict: " + truncateRunes(strings.Join(strings.Fields(content), " "), 200)
+	}
+	return verdict.Pass, verdict.Reason
+}
+
+// extractJSON returns the JSON in an answer, dropping a surrounding
+// markdown code fence or text around a single object or array.
+func extractJSON(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		if _, rest, ok := strings.Cut(s, "\n"); ok {
+			s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+		}
+	}
+	if json.Valid([]byte(s)) {
+		return s
+	}
+	for _, pair := range [][2]string{{"{", "}"}, {"[", "]"}} {
+		start, end := strings.Index(s, pair[0]), strings.LastIndex(s, pair[1])
+		if start >= 0 && end > start && json.Valid([]byte(s[start:end+1])) {
+			return s[start : end+1]
+		}
+	}
+	return s
+}
+
+// yamlToJSON converts the maps decoded by yaml.v2 to the map[string]
+// interface{} form encoding/json produces.
+func yamlToJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = yamlToJSON(val)
+		}
+		return m
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = yamlToJSON(val)
+		}
+		return out
+	}
+	return v
+}
+
+// printEvalMatrix prints a row per case and a column per model, followed by
+// the details of every failure.
+func printEvalMatrix(suite *evalSuite, models []string, results []evalResult) {
+	caseWidth := len("Case")
+	for _, c := range suite.Cases {
+		caseWidth = max(caseWidth, utf8.RuneCountInString(c.Name))
+	}
+	caseWidth = min(caseWidth, 40)
+
+	widths := make([]int, len(models))
+	fmt.Printf("\n%s", padVisible(cyan("Case"), caseWidth))
+	for j, model := range models {
+		widths[j] = max(utf8.RuneCountInString(model), len("ERROR"))
+		fmt.Printf("  %s", padVisible(cyan(model), widths[j]))
+	}
+	fmt.Println()
+
+	passed := 0
+	for i, c := range suite.Cases {
+		fmt.Print(padVisible(truncateRunes(c.Name, caseWidth), caseWidth))
+		for j := range models {
+			r := results[i*len(models)+j]
+			cell := red("FAIL")
+			switch {
+			case r.Error != "":
+				cell = red("ERROR")
+			case r.Pass:
+				cell = green("PASS")
+				passed++
+			}
+			fmt.Printf("  %s", padVisible(cell, widths[j]))
+		}
+		fmt.Println()
+	}
+
+	for _, r := range results {
+		if r.Pass {
+			continue
+		}
+		fmt.Printf("\n%s %s\n", yellow(r.Case), faint("("+r.Model+")"))
+		if r.Error != "" {
+			fmt.Printf("  %s %s\n", red("Error:"), r.Error)
+			continue
+		}
+		for _, check := range r.Assertions {
+			if check.Pass {
+				continue
+			}
+			line := fmt.Sprintf("  %s %s", red("✗"), check.Assertion)
+			if check.Detail != "" {
+				line += faint(" · " + check.Detail)
+			}
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Printf("\n%d of %d passed\n", passed, len(results))
+}
+
+func init() {
+	evalCmd.Flags().StringSliceVarP(&evalModels, "model", "m", nil, "Model to evaluate, overriding the suite's models (repeat or comma-separate for several)")
+	evalCmd.Flags().IntVarP(&evalConcurrency, "concurrency", "c", 4, "Number of cases to run at once")
+	evalCmd.Flags().StringVar(&evalReport, "report", "", "Path of the JSON report (default <suite>.report.json)")
+	addGenerationFlags(evalCmd)
+	rootCmd.AddCommand(evalCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+)
+
+// completeWithFallback sends req to provider and, when the request fails with
+// a retryable error such as a rate limit or outage, tries each model of the
+// configured fallback_models list in turn until one succeeds. It returns the
+// response along with the provider and model that actually answered. params,
+// unless nil, sets the sampling parameters of each fallback model; with nil
+// they are kept.
+func completeWithFallback(cfg *config.Config, provider config.Provider, req *api.ChatCompletionRequest, params paramsFunc) (*api.ChatCompletionResponse, config.Provider, string, error) {
+	resp, err := getResponse(provider, cfg, req)
+	if err == nil || !api.IsRetryable(err) {
+		return resp, provider, req.Model, err
+	}
+
+	tried := map[string]bool{fmt.Sprintf("%s/%s", provider, req.Model): true}
+	lastErr := err
+	for _, name := range cfg.FallbackModels {
+		fallbackProvider, fallbackModel, resolveErr := config.ResolveModel(name, cfg)
+		if resolveErr != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", yellow("Skipping fallback:"), resolveErr)
+			continue
+		}
+
+		qualified := fmt.Sprintf("%s/%s", fallbackProvider, fallbackModel)
+		if tried[qualified] {
+			continue
+		}
+		tried[qualified] = true
+
+		fmt.Fprintf(os.Stderr, "%s %v\n%s %s\n", yellow("Request failed:"), lastErr, yellow("Falling back to"), qualified)
+
+		attempt := *req
+		attempt.Model = fallbackModel
+		if params != nil {
+			params(fallbackProvider, &attempt)
+		}
+		resp, err = getResponse(fallbackProvider, cfg, &attempt)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%s %s\n", yellow("Answered by"), qualified)
+			return resp, fallbackProvider, fallbackModel, nil
+		}
+		// Once the primary model is down, any failing fallback just moves
+		// on to the next one
+		lastErr = err
+	}
+
+	return nil, provider, req.Model, lastErr
+}
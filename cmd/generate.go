@@ -2,68 +2,104 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/tedfulk/suggest/internal/config"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	generateForce bool
+	generateMerge bool
+)
+
 var generateConfigCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate a template configuration file",
+	Long: `Generate a template configuration file.
+
+An existing config file is never overwritten unless --force is given.
+Use --merge to add any missing default system prompts and templates to
+an existing config without touching your keys or settings. Without a
+config, --merge generates one like a plain 'suggest generate'.
+
+Example:
+  suggest generate           # Create a config if none exists
+  suggest generate --merge   # Add missing default prompts and templates
+  suggest generate --force   # Replace the config with the defaults
+  suggest init               # Interactive setup wizard`,
 	Run: func(cmd *cobra.Command, args []string) {
-		homeDir, err := os.UserHomeDir()
+		if generateForce && generateMerge {
+			fmt.Println("Use either --force or --merge, not both")
+			return
+		}
+
+		configPath, err := config.ConfigPath()
 		if err != nil {
 			fmt.Println("Error finding home directory:", err)
 			return
 		}
 
-		configPath := filepath.Join(homeDir, ".config", "suggest", "config.yml")
-		err = os.MkdirAll(filepath.Dir(configPath), os.ModePerm)
+		exists, err := config.ConfigExists()
 		if err != nil {
-			fmt.Println("Error creating config directory:", err)
+			fmt.Println("Error checking config file:", err)
 			return
 		}
 
-		defaultPrompts := []config.SystemPrompt{
-			{
-				Title:   "Helpful Assistant",
-				Content: "You are a helpful assistant. You provide clear, accurate, and concise responses. When discussing code, you use markdown formatting and include helpful comments.",
-			},
-			{
-				Title:   "Programming Assistant",
-				Content: "You are a programming assistant. You help write, explain, and debug code.",
-			},
-			{
-				Title:   "Technical Writer",
-				Content: "You are a technical writer. You help create clear documentation and explanations.",
-			},
+		// Without a config there is nothing to merge into, so generate one
+		if generateMerge && !exists {
+			fmt.Println("No configuration file yet, generating one with the defaults")
 		}
 
-		defaultTemplates := []config.Template{
-			{
-				Title:   "Code Function",
-				Content: "Write a [language] function that [task]",
-			},
-			{
-				Title:   "Code Review",
-				Content: "Review this [language] code:\n[code]",
-			},
+		if generateMerge && exists {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				fmt.Println("Error loading config:", err)
+				return
+			}
+
+			addedPrompts, addedTemplates := config.MergeDefaults(cfg)
+			if len(addedPrompts) == 0 && len(addedTemplates) == 0 {
+				fmt.Println("Config already contains all default system prompts and templates")
+				return
+			}
+
+			err = config.SaveConfig(cfg)
+			if err != nil {
+				fmt.Println("Error saving config:", err)
+				return
+			}
+
+			fmt.Println("Configuration merged at", configPath)
+			for _, title := range addedPrompts {
+				fmt.Printf("  Added system prompt: %s\n", title)
+			}
+			for _, title := range addedTemplates {
+				fmt.Printf("  Added template: %s\n", title)
+			}
+			return
 		}
 
+		if exists && !generateForce {
+			fmt.Println("Configuration file already exists at", configPath)
+			fmt.Println("\nUse 'suggest generate --merge' to add missing default prompts and templates")
+			fmt.Println("Use 'suggest generate --force' to overwrite it with the defaults")
+			return
+		}
+
+		defaultPrompts := config.DefaultSystemPrompts()
+
 		cfg := config.Config{
-			OpenAIAPIKey:   "your-openai-api-key",
-			GroqAPIKey:     "your-groq-api-key",
-			GeminiAPIKey:   "your-gemini-api-key",
-			TavilyAPIKey:   "your-tavily-api-key",
-			OllamaHost:     "http://localhost:11434",
-			SystemPrompt:   defaultPrompts[0].Content,
-			SystemPrompts:  defaultPrompts,
+			OpenAIAPIKey:  "your-openai-api-key",
+			GroqAPIKey:    "your-groq-api-key",
+			GeminiAPIKey:  "your-gemini-api-key",
+			TavilyAPIKey:  "your-tavily-api-key",
+			OllamaHost:    "http://localhost:11434",
+			SystemPrompt:  defaultPrompts[0].Content,
+			SystemPrompts: defaultPrompts,
 			Model:         "llama-3.3-70b-versatile",
-			ModelAliases: make(map[string]string),
-			Templates:    defaultTemplates,
+			ModelAliases:  make(map[string]string),
+			Templates:     config.DefaultTemplates(),
 		}
 
 		err = config.SaveConfig(&cfg)
@@ -77,9 +113,12 @@ var generateConfigCmd = &cobra.Command{
 		fmt.Printf("  %s\n", defaultPrompts[0].Content)
 		fmt.Println("\nUse 'suggest system list' to see all available system prompts")
 		fmt.Println("Use 'suggest system set \"your prompt\"' to change the active system prompt")
+		fmt.Println("Use 'suggest init' to set up your API keys and default model interactively")
 	},
 }
 
 func init() {
+	generateConfigCmd.Flags().BoolVarP(&generateForce, "force", "f", false, "Overwrite an existing config file")
+	generateConfigCmd.Flags().BoolVar(&generateMerge, "merge", false, "Add missing default prompts and templates to an existing config")
 	rootCmd.AddCommand(generateConfigCmd)
 }
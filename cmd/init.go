@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// preferredModels lists the models the setup wizard picks as the default,
+// in order of preference, when the matching provider is available.
+var preferredModels = []struct {
+	provider config.Provider
+	model    string
+}{
+	{config.ProviderGroq, "llama-3.3-70b-versatile"},
+	{config.ProviderOpenAI, "gpt-4o-mini"},
+	{config.ProviderGemini, "gemini-2.0-flash"},
+	{config.ProviderGemini, "gemini-1.5-flash"},
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Interactively set up API keys and a default model",
+	Long: `Interactively set up suggest.
+
+The wizard detects a local Ollama server, asks for your provider API keys and
+validates each one by listing the provider's models, then picks a sensible
+default model from what is available. Existing settings are kept unless you
+replace them, and missing default system prompts and templates are added.
+
+Example:
+  suggest init`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		config.MergeDefaults(cfg)
+		available := make(map[config.Provider][]string)
+
+		fmt.Println(cyan("Welcome to suggest!"), "Press Enter to keep the current value of any setting.")
+
+		// Ollama needs no key, so just check whether a server is reachable
+		if cfg.OllamaHost == "" {
+			cfg.OllamaHost = "http://localhost:11434"
+		}
+		fmt.Printf("\nLooking for Ollama at %s...\n", cfg.OllamaHost)
+		if models, err := config.FetchModels(config.ProviderOllama, cfg); err != nil {
+			fmt.Println("Ollama not detected. Run 'suggest keys ollama' later if it lives elsewhere.")
+		} else {
+			fmt.Printf("Ollama detected with %d model(s)\n", len(models))
+			available[config.ProviderOllama] = models
+		}
+
+		providers := []struct {
+			name     string
+			provider config.Provider
+			key      *string
+		}{
+			{"Groq", config.ProviderGroq, &cfg.GroqAPIKey},
+			{"OpenAI", config.ProviderOpenAI, &cfg.OpenAIAPIKey},
+			{"Gemini", config.ProviderGemini, &cfg.GeminiAPIKey},
+		}
+
+		for _, p := range providers {
+			fmt.Println()
+			for {
+				key, err := promptKey(p.name, *p.key)
+				if err != nil {
+					fmt.Printf("Prompt failed %v\n", err)
+					return
+				}
+				if key == "" {
+					if *p.key != "" {
+						if models, err := config.FetchModels(p.provider, cfg); err != nil {
+							fmt.Printf("Warning: current %s API key could not be validated: %v\n", p.name, err)
+						} else {
+							available[p.provider] = models
+						}
+					}
+					break
+				}
+
+				previous := *p.key
+				*p.key = key
+				models, err := config.FetchModels(p.provider, cfg)
+				if err != nil {
+					fmt.Printf("%s API key could not be validated: %v\n", p.name, err)
+					*p.key = previous
+					if previous != "" {
+						fmt.Println("Keeping the previous key. Enter another key or press Enter to skip.")
+					} else {
+						fmt.Println("Enter another key or press Enter to skip.")
+					}
+					continue
+				}
+
+				fmt.Printf("%s API key is valid (%d models available)\n", p.name, len(models))
+				available[p.provider] = models
+				break
+			}
+		}
+
+		fmt.Println()
+		for _, extra := range []struct {
+			name string
+			key  *string
+		}{
+			{"Tavily", &cfg.TavilyAPIKey},
+			{"Hume", &cfg.HumeAPIKey},
+		} {
+			key, err := promptKey(extra.name, *extra.key)
+			if err != nil {
+				fmt.Printf("Prompt failed %v\n", err)
+				return
+			}
+			if key != "" {
+				*extra.key = key
+			}
+		}
+
+		if cfg.Username == "" {
+			usernamePrompt := promptui.Prompt{
+				Label: "Username for chat sessions (optional)",
+			}
+			username, err := usernamePrompt.Run()
+			if err != nil {
+				fmt.Printf("Prompt failed %v\n", err)
+				return
+			}
+			cfg.Username = strings.TrimSpace(username)
+		}
+
+		if model := pickDefaultModel(cfg.Model, available); model != "" {
+			cfg.Model = model
+		}
+
+		err = config.SaveConfig(cfg)
+		if err != nil {
+			fmt.Println("Error saving config:", err)
+			return
+		}
+
+		configPath, _ := config.ConfigPath()
+		fmt.Println("\nConfiguration saved to", configPath)
+		if cfg.Model != "" {
+			fmt.Printf("Default model: %s\n", cyan(cfg.Model))
+			fmt.Println("Use 'suggest model' to choose a different model")
+		} else {
+			fmt.Println("No provider is available yet. Add a key with 'suggest keys <provider>' or start Ollama.")
+		}
+	},
+}
+
+// promptKey asks for an API key, showing a masked version of the current one.
+// An empty result means the current value should be kept.
+func promptKey(name, current string) (string, error) {
+	label := fmt.Sprintf("%s API key", name)
+	if current != "" {
+		label = fmt.Sprintf("%s API key [%s]", name, maskKey(current))
+	} else {
+		label += " (optional)"
+	}
+
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+	}
+	key, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// pickDefaultModel keeps the current model when a validated provider offers
+// it, otherwise it picks the first preferred model that is available, falling
+// back to the first local Ollama model.
+func pickDefaultModel(current string, available map[config.Provider][]string) string {
+	for _, models := range available {
+		for _, m := range models {
+			if m == current {
+				return current
+			}
+		}
+	}
+
+	for _, preferred := range preferredModels {
+		for _, m := range available[preferred.provider] {
+			if m == preferred.model {
+				return m
+			}
+		}
+	}
+
+	if models := available[config.ProviderOllama]; len(models) > 0 {
+		return models[0]
+	}
+
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
@@ -68,13 +68,7 @@ Example:
 					return
 				}
 				fmt.Println("OpenAI API key updated")
-				fmt.Println("Updating available models...")
-				_, err = config.FetchModels(config.ProviderOpenAI, cfg)
-				if err != nil {
-					fmt.Println("Error updating models:", err)
-					return
-				}
-				fmt.Println("Models list updated")
+				refreshModelCatalog(cfg, config.ProviderOpenAI)
 			}
 
 		case "groq":
@@ -89,13 +83,7 @@ Example:
 					return
 				}
 				fmt.Println("Groq API key updated")
-				fmt.Println("Updating available models...")
-				_, err = config.FetchModels(config.ProviderGroq, cfg)
-				if err != nil {
-					fmt.Println("Error updating models:", err)
-					return
-				}
-				fmt.Println("Models list updated")
+				refreshModelCatalog(cfg, config.ProviderGroq)
 			}
 
 		case "gemini":
@@ -110,13 +98,7 @@ Example:
 					return
 				}
 				fmt.Println("Gemini API key updated")
-				fmt.Println("Updating available models...")
-				_, err = config.FetchModels(config.ProviderGemini, cfg)
-				if err != nil {
-					fmt.Println("Error updating models:", err)
-					return
-				}
-				fmt.Println("Models list updated")
+				refreshModelCatalog(cfg, config.ProviderGemini)
 			}
 
 		case "tavily":
@@ -167,6 +149,23 @@ Example:
 	},
 }
 
+// refreshModelCatalog updates the cached model list of a provider after its
+// key has changed.
+func refreshModelCatalog(cfg *config.Config, provider config.Provider) {
+	fmt.Println("Updating available models...")
+	catalog, err := config.RefreshCatalog(cfg, provider)
+	if err != nil {
+		fmt.Println("Error saving model catalog:", err)
+		return
+	}
+	entry := catalog.Providers[provider]
+	if entry.Error != "" {
+		fmt.Println("Error updating models:", entry.Error)
+		return
+	}
+	fmt.Printf("Models list updated (%d models)\n", len(entry.Models))
+}
+
 func init() {
 	rootCmd.AddCommand(keysCmd)
 } 
\ No newline at end of file
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/mcp"
+	"github.com/tedfulk/suggest/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	mcpConnectTimeout = 15 * time.Second
+	mcpCallTimeout    = 60 * time.Second
+	// maxListedResources bounds how many resources are listed in the
+	// description of a server's read_resource tool
+	maxListedResources = 50
+)
+
+// mcpConnection is an initialized client of a configured MCP server.
+type mcpConnection struct {
+	Name      string
+	Client    *mcp.Client
+	Tools     []mcp.Tool
+	Resources []mcp.Resource
+}
+
+var toolNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Work with Model Context Protocol servers",
+	Long: `Connect suggest to Model Context Protocol (MCP) servers.
+
+The tools of every configured server are offered to the model alongside the
+built-in tools when you pass --tools to 'suggest' or 'suggest chat'.
+Servers are started as a command speaking MCP over stdio, or reached over
+HTTP when a url is given:
+
+  mcp_servers:
+    - name: docs
+      command: npx
+      args: ["-y", "@acme/docs-mcp"]
+      env:
+        DOCS_TOKEN: secret
+    - name: db
+      url: http://localhost:3001/mcp
+      headers:
+        Authorization: Bearer secret
+
+Example:
+  suggest mcp list
+  suggest --tools "What does our style guide say about error messages?"
+  suggest chat --tools`,
+}
+
+var mcpListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tools and resources of the configured MCP servers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		if len(cfg.MCPServers) == 0 {
+			fmt.Println("No MCP servers configured. Add them under mcp_servers in your config, see 'suggest mcp --help'")
+			return
+		}
+
+		for _, server := range cfg.MCPServers {
+			fmt.Printf("\n%s\n", cyan(server.Name))
+			if server.Disabled {
+				fmt.Println(faint("  disabled"))
+				continue
+			}
+
+			conn, err := connectMCPServer(server)
+			if err != nil {
+				fmt.Printf("  Error: %v\n", err)
+				continue
+			}
+			conn.Client.Close()
+
+			if conn.Client.Server.Name != "" {
+				fmt.Printf("  %s %s %s\n", faint("Server:"), conn.Client.Server.Name, conn.Client.Server.Version)
+			}
+			fmt.Printf("  %s\n", faint("Tools:"))
+			if len(conn.Tools) == 0 {
+				fmt.Println("    none")
+			}
+			for _, t := range conn.Tools {
+				fmt.Printf("    %s %s\n", green(t.Name), firstLine(t.Description))
+			}
+			if len(conn.Resources) > 0 {
+				fmt.Printf("  %s\n", faint("Resources:"))
+				for _, r := range conn.Resources {
+					fmt.Printf("    %s %s\n", green(r.URI), r.Name)
+				}
+			}
+		}
+	},
+}
+
+// connectMCPServer starts or connects to a server, performs the handshake
+// and lists its tools and resources.
+func connectMCPServer(server config.MCPServer) (*mcpConnection, error) {
+	var client *mcp.Client
+	switch {
+	case server.URL != "":
+		client = mcp.NewHTTPClient(server.URL, server.Headers)
+	case server.Command != "":
+		var err error
+		client, err = mcp.NewStdioClient(server.Command, server.Args, server.Env)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("MCP server '%s' needs a command or a url", server.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mcpConnectTimeout)
+	defer cancel()
+
+	if err := client.Initialize(ctx, mcp.Implementation{Name: "suggest", Version: version}); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error initializing: %w", err)
+	}
+
+	conn := &mcpConnection{Name: server.Name, Client: client}
+	if client.HasCapability("tools") {
+		t, err := client.ListTools(ctx)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("error listing tools: %w", err)
+		}
+		conn.Tools = t
+	}
+	if client.HasCapability("resources") {
+		// Resources are optional extras, so a failure here isn't fatal
+		if r, err := client.ListResources(ctx); err == nil {
+			conn.Resources = r
+		}
+	}
+	return conn, nil
+}
+
+// connectMCPServers connects to every enabled server concurrently, warning
+// about the ones that fail. The returned function closes the connections.
+func connectMCPServers(cfg *config.Config) ([]*mcpConnection, func()) {
+	results := make([]*mcpConnection, len(cfg.MCPServers))
+	var wg sync.WaitGroup
+	for i, server := range cfg.MCPServers {
+		if server.Disabled {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, server config.MCPServer) {
+			defer wg.Done()
+			conn, err := connectMCPServer(server)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s %s: %v\n", yellow("Skipping MCP server"), server.Name, err)
+				return
+			}
+			results[i] = conn
+		}(i, server)
+	}
+	wg.Wait()
+
+	var conns []*mcpConnection
+	for _, conn := range results {
+		if conn != nil {
+			conns = append(conns, conn)
+		}
+	}
+	return conns, func() {
+		for _, conn := range conns {
+			conn.Client.Close()
+		}
+	}
+}
+
+// mcpTools wraps the tools of a server, named "<server>__<tool>", plus a
+// read_resource tool when the server has resources.
+func (c *mcpConnection) mcpTools() []tools.Tool {
+	var result []tools.Tool
+	for _, t := range c.Tools {
+		name := t.Name
+		schema := t.InputSchema
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		result = append(result, tools.Tool{
+			Name:        c.toolName(name),
+			Description: fmt.Sprintf("%s (from the %s MCP server)", t.Description, c.Name),
+			Parameters:  schema,
+			Run: func(args map[string]interface{}) (string, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
+				defer cancel()
+				res, err := c.Client.CallTool(ctx, name, args)
+				if err != nil {
+					return "", err
+				}
+				if res.IsError {
+					return "", fmt.Errorf("%s", res.Text())
+				}
+				return res.Text(), nil
+			},
+		})
+	}
+
+	if len(c.Resources) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Read a resource of the %s MCP server by URI. Available resources:", c.Name)
+		for i, r := range c.Resources {
+			if i == maxListedResources {
+				fmt.Fprintf(&b, "\n- ... and %d more", len(c.Resources)-maxListedResources)
+				break
+			}
+			fmt.Fprintf(&b, "\n- %s: %s", r.URI, r.Name)
+			if r.Description != "" {
+				fmt.Fprintf(&b, " (%s)", firstLine(r.Description))
+			}
+		}
+
+		result = append(result, tools.Tool{
+			Name:        c.toolName("read_resource"),
+			Description: b.String(),
+			Parameters: tools.Object(map[string]string{
+				"uri": "URI of the resource to read",
+			}, "uri"),
+			Run: func(args map[string]interface{}) (string, error) {
+				uri, err := tools.StringArg(args, "uri", true)
+				if err != nil {
+					return "", err
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
+				defer cancel()
+				contents, err := c.Client.ReadResource(ctx, uri)
+				if err != nil {
+					return "", err
+				}
+				var parts []string
+				for _, content := range contents {
+					if content.Text != "" {
+						parts = append(parts, content.Text)
+					} else {
+						parts = append(parts, fmt.Sprintf("[binary content, %s]", content.MIMEType))
+					}
+				}
+				return strings.Join(parts, "\n"), nil
+			},
+		})
+	}
+	return result
+}
+
+// toolName builds a provider-safe tool name: letters, digits, '_' and '-',
+// at most 64 characters.
+func (c *mcpConnection) toolName(tool string) string {
+	name := toolNameInvalid.ReplaceAllString(c.Name+"__"+tool, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func init() {
+	mcpCmd.AddCommand(mcpListCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/mcp"
+	"github.com/tedfulk/suggest/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+const mcpServerInstructions = `Use the ask tool to query language models through the user's suggest configuration: model aliases, saved system prompts and prompt templates. The templates and system prompts are listed as resources.`
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run suggest as an MCP server over stdio",
+	Long: `Run suggest as a Model Context Protocol server over stdin and stdout, so
+editors and other agents can use your suggest configuration.
+
+Tools:
+  ask     Ask a model, optionally with a system prompt, template and variables
+  search  Search the web with Tavily (needs a Tavily API key)
+  tts     Turn text into speech with Groq or Hume (needs an API key)
+
+Resources:
+  suggest://templates/<title>       Your templates
+  suggest://system-prompts/<title>  Your system prompts
+  suggest://model-aliases           Your model aliases
+
+Example configuration for an MCP client:
+  {
+    "mcpServers": {
+      "suggest": {"command": "suggest", "args": ["mcp", "serve"]}
+    }
+  }`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading config:", err)
+			return
+		}
+
+		// stdin carries the protocol, so templates mustn't read it
+		utils.IgnoreStdin()
+		server := newSuggestMCPServer(cmd, cfg)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		// stdout carries the protocol, so everything else goes to stderr
+		if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+	},
+}
+
+func newSuggestMCPServer(cmd *cobra.Command, cfg *config.Config) *mcp.Server {
+	server := mcp.NewServer(mcp.Implementation{Name: "suggest", Version: version})
+	server.Instructions = mcpServerInstructions
+
+	server.AddTool(mcp.Tool{
+		Name:        "ask",
+		Description: "Ask a language model. Model aliases, system prompt titles and template titles from the suggest configuration can be used.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"prompt": map[string]interface{}{
+					"type":        "string",
+					"description": "The message to send; appended to the template when one is given",
+				},
+				"model": map[string]interface{}{
+					"type":        "string",
+					"description": "Model ID, provider/model or alias; defaults to the configured model",
+				},
+				"system_prompt": map[string]interface{}{
+					"type":        "string",
+					"description": "Title of a saved system prompt; defaults to the active one",
+				},
+				"template": map[string]interface{}{
+					"type":        "string",
+					"description": "Title of a saved template to use as the message",
+				},
+				"vars": map[string]interface{}{
+					"type":                 "object",
+					"description":          "Values for the template's [variables]",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+	}, func(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+		return mcpAsk(cmd, cfg, args)
+	})
+
+	if cfg.TavilyAPIKey != "" {
+		server.AddTool(mcp.Tool{
+			Name:        "search",
+			Description: "Search the web with Tavily and return an answer with the top results",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "The search query",
+					},
+					"topic": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"general", "news"},
+						"description": "Search topic",
+					},
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of results, 5 by default",
+					},
+				},
+				"required": []string{"query"},
+			},
+		}, func(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+			return mcpSearch(cfg, args)
+		})
+	}
+
+	if cfg.GroqAPIKey != "" || cfg.HumeAPIKey != "" {
+		server.AddTool(mcp.Tool{
+			Name:        "tts",
+			Description: "Turn text into speech and return the audio",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "The text to speak",
+					},
+					"voice": map[string]interface{}{
+						"type":        "string",
+						"description": "A Groq voice name such as Fritz-PlayAI, or a voice description for Hume",
+					},
+				},
+				"required": []string{"text"},
+			},
+		}, func(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+			return mcpTTS(cfg, args)
+		})
+	}
+
+	for _, t := range cfg.Templates {
+		t := t
+		server.AddResource(mcp.Resource{
+			URI:         "suggest://templates/" + url.PathEscape(t.Title),
+			Name:        t.Title,
+			Description: "Prompt template",
+			MIMEType:    "text/plain",
+		}, func(ctx context.Context) (mcp.ResourceContents, error) {
+			return mcp.ResourceContents{
+				URI:      "suggest://templates/" + url.PathEscape(t.Title),
+				MIMEType: "text/plain",
+				Text:     t.Content,
+			}, nil
+		})
+	}
+
+	for _, p := range cfg.SystemPrompts {
+		p := p
+		description := "System prompt"
+		if p.Content == cfg.SystemPrompt {
+			description = "System prompt (active)"
+		}
+		server.AddResource(mcp.Resource{
+			URI:         "suggest://system-prompts/" + url.PathEscape(p.Title),
+			Name:        p.Title,
+			Description: description,
+			MIMEType:    "text/plain",
+		}, func(ctx context.Context) (mcp.ResourceContents, error) {
+			return mcp.ResourceContents{
+				URI:      "suggest://system-prompts/" + url.PathEscape(p.Title),
+				MIMEType: "text/plain",
+				Text:     p.Content,
+			}, nil
+		})
+	}
+
+	server.AddResource(mcp.Resource{
+		URI:         "suggest://model-aliases",
+		Name:        "Model aliases",
+		Description: "Aliases usable as the model of the ask tool",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context) (mcp.ResourceContents, error) {
+		data, err := json.MarshalIndent(cfg.ModelAliases, "", "  ")
+		if err != nil {
+			return mcp.ResourceContents{}, err
+		}
+		return mcp.ResourceContents{
+			URI:      "suggest://model-aliases",
+			MIMEType: "application/json",
+			Text:     string(data),
+		}, nil
+	})
+
+	return server
+}
+
+func mcpAsk(cmd *cobra.Command, cfg *config.Config, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	prompt, _ := args["prompt"].(string)
+	model, _ := args["model"].(string)
+	systemTitle, _ := args["system_prompt"].(string)
+	templateTitle, _ := args["template"].(string)
+
+	vars := make(map[string]string)
+	if raw, ok := args["vars"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			vars[k] = fmt.Sprint(v)
+		}
+	}
+	message, err := composeMessage(cfg, templateTitle, vars, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("provide a prompt or a template")
+	}
+
+	systemPrompt, err := resolveSystemPrompt(cfg, systemTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	if model == "" {
+		model = cfg.Model
+	}
+	provider, model, err := config.ResolveModel(model, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &api.ChatCompletionRequest{
+		Model:    model,
+		Messages: buildMessages(systemPrompt, message),
+	}
+	params := generationParams(cmd, cfg, api.Float(0.7))
+	params(provider, req)
+
+	resp, _, _, err := completeWithFallback(cfg, provider, req, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response received from model")
+	}
+	return mcp.TextResult(resp.Choices[0].Message.Content), nil
+}
+
+func mcpSearch(cfg *config.Config, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("missing argument 'query'")
+	}
+
+	req := api.TavilySearchRequest{
+		Query:         query,
+		APIKey:        cfg.TavilyAPIKey,
+		SearchDepth:   "basic",
+		MaxResults:    5,
+		IncludeAnswer: true,
+	}
+	if topic, ok := args["topic"].(string); ok {
+		req.Topic = topic
+	}
+	if n, ok := args["max_results"].(float64); ok && n > 0 {
+		req.MaxResults = int(n)
+	}
+
+	resp, err := api.NewTavilyClient(cfg.TavilyAPIKey).SearchWithOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if resp.Answer != "" {
+		fmt.Fprintf(&b, "Answer: %s\n\n", resp.Answer)
+	}
+	for _, r := range resp.Results {
+		fmt.Fprintf(&b, "%s\n%s\n%s\n\n", r.Title, r.URL, r.Content)
+	}
+	return mcp.TextResult(b.String()), nil
+}
+
+// mcpTTS generates speech with Groq, or with Hume when only a Hume key is
+// set, and returns it as audio content.
+func mcpTTS(cfg *config.Config, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	text, _ := args["text"].(string)
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("missing argument 'text'")
+	}
+	voice, _ := args["voice"].(string)
+	text = cleanTextForSpeech(text)
+
+	var audio []byte
+	var mimeType string
+	var err error
+	if cfg.GroqAPIKey != "" {
+		if voice == "" {
+			voice = "Fritz-PlayAI"
+		}
+		audio, err = api.NewGroqClient(cfg.GroqAPIKey).CreateTTS(text, voice)
+		mimeType = "audio/wav"
+	} else {
+		if voice == "" {
+			voice = "Booming American Narrator"
+		}
+		audio, err = api.NewHumeClient(cfg.HumeAPIKey).CreateTTS(text, voice)
+		mimeType = "audio/mpeg"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.CallToolResult{Content: []mcp.Content{{
+		Type:     "audio",
+		MIMEType: mimeType,
+		Data:     base64.StdEncoding.EncodeToString(audio),
+	}}}, nil
+}
+
+func init() {
+	mcpCmd.AddCommand(mcpServeCmd)
+}
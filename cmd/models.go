@@ -3,15 +3,39 @@ package cmd
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/tedfulk/suggest/internal/config"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	modelsRefresh bool
+	modelsOffline bool
+)
+
+var providerNames = map[config.Provider]string{
+	config.ProviderOpenAI: "OpenAI",
+	config.ProviderGroq:   "Groq",
+	config.ProviderGemini: "Gemini",
+	config.ProviderOllama: "Ollama",
+}
+
 var modelsCmd = &cobra.Command{
 	Use:   "models",
 	Short: "List available models",
+	Long: `List the models available from every configured provider.
+
+Model lists are cached in ~/.cache/suggest/models.json and refreshed
+concurrently once they are older than model_cache_ttl (default 1h).
+If a provider cannot be reached, its last cached list is shown instead.
+
+Example:
+  suggest models             # Use the cache, refreshing stale providers
+  suggest models --refresh   # Fetch every provider again
+  suggest models --offline   # Only use the cache`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.LoadConfig()
 		if err != nil {
@@ -19,54 +43,35 @@ var modelsCmd = &cobra.Command{
 			return
 		}
 
-		// Fetch models from each configured provider
-		if cfg.OpenAIAPIKey != "" {
-			fmt.Println("\nOpenAI models:")
-			models, err := config.FetchModels(config.ProviderOpenAI, cfg)
-			if err != nil {
-				fmt.Printf("Error fetching OpenAI models: %v\n", err)
-			} else {
-				sort.Strings(models)
-				for _, model := range models {
-					printModelWithAliases(model, cfg.ModelAliases)
-				}
-			}
+		var catalog *config.Catalog
+		if modelsOffline {
+			catalog, err = config.LoadCatalog()
+		} else {
+			catalog, err = config.GetCatalog(cfg, modelsRefresh)
 		}
-
-		if cfg.GroqAPIKey != "" {
-			fmt.Println("\nGroq models:")
-			models, err := config.FetchModels(config.ProviderGroq, cfg)
-			if err != nil {
-				fmt.Printf("Error fetching Groq models: %v\n", err)
-			} else {
-				sort.Strings(models)
-				for _, model := range models {
-					printModelWithAliases(model, cfg.ModelAliases)
-				}
+		if err != nil {
+			fmt.Println("Error loading model catalog:", err)
+			if catalog == nil {
+				return
 			}
 		}
 
-		if cfg.GeminiAPIKey != "" {
-			fmt.Println("\nGemini models:")
-			models, err := config.FetchModels(config.ProviderGemini, cfg)
-			if err != nil {
-				fmt.Printf("Error fetching Gemini models: %v\n", err)
-			} else {
-				sort.Strings(models)
-				for _, model := range models {
-					printModelWithAliases(model, cfg.ModelAliases)
+		for _, provider := range config.ConfiguredProviders(cfg) {
+			fmt.Printf("\n%s models:\n", providerNames[provider])
+
+			entry := catalog.Providers[provider]
+			if entry == nil {
+				fmt.Println("  No cached models. Run 'suggest models --refresh' while online.")
+				continue
+			}
+			if entry.Error != "" {
+				fmt.Printf("  Error: %s\n", entry.Error)
+				if len(entry.Models) > 0 {
+					fmt.Printf("  Showing cached list from %s\n", formatAge(entry.FetchedAt))
 				}
 			}
-		}
 
-		// Add Ollama models section
-		fmt.Println("\nOllama models:")
-		models, err := config.FetchModels(config.ProviderOllama, cfg)
-		if err != nil {
-			fmt.Printf("Error fetching Ollama models: %v\n", err)
-		} else {
-			sort.Strings(models)
-			for _, model := range models {
+			for _, model := range entry.Models {
 				printModelWithAliases(model, cfg.ModelAliases)
 			}
 		}
@@ -74,20 +79,69 @@ var modelsCmd = &cobra.Command{
 }
 
 func init() {
+	modelsCmd.Flags().BoolVarP(&modelsRefresh, "refresh", "r", false, "Fetch model lists from every provider, ignoring the cache")
+	modelsCmd.Flags().BoolVar(&modelsOffline, "offline", false, "Only show cached model lists")
 	rootCmd.AddCommand(modelsCmd)
 }
 
-func printModelWithAliases(model string, aliases map[string]string) {
+func printModelWithAliases(model config.ModelInfo, aliases map[string]string) {
 	modelAliases := []string{}
 	for alias, m := range aliases {
-		if m == model {
+		if m == model.ID {
 			modelAliases = append(modelAliases, alias)
 		}
 	}
+	sort.Strings(modelAliases)
 
+	line := fmt.Sprintf("  %s", model.ID)
+	if details := formatModelDetails(model); details != "" {
+		line += " " + faint(details)
+	}
 	if len(modelAliases) > 0 {
-		fmt.Printf("  %s (aliases: %v)\n", model, modelAliases)
-	} else {
-		fmt.Printf("  %s\n", model)
+		line += fmt.Sprintf(" (aliases: %v)", modelAliases)
+	}
+	fmt.Println(line)
+}
+
+// formatModelDetails summarises the capabilities and pricing of a model,
+// e.g. "[128k ctx, vision, tools, $2.50/$10.00 per 1M]".
+func formatModelDetails(model config.ModelInfo) string {
+	var details []string
+	if model.ContextLength > 0 {
+		details = append(details, fmt.Sprintf("%s ctx", formatTokens(model.ContextLength)))
+	}
+	if model.Vision {
+		details = append(details, "vision")
 	}
-} 
\ No newline at end of file
+	if model.Tools {
+		details = append(details, "tools")
+	}
+	if model.InputPrice > 0 || model.OutputPrice > 0 {
+		details = append(details, fmt.Sprintf("$%.2f/$%.2f per 1M", model.InputPrice, model.OutputPrice))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(details, ", ") + "]"
+}
+
+func formatTokens(n int) string {
+	if n >= 1000000 {
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	}
+	if n >= 1000 {
+		return fmt.Sprintf("%dk", n/1000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "an unknown time"
+	}
+	age := time.Since(t).Round(time.Minute)
+	if age < time.Minute {
+		return "just now"
+	}
+	return strings.TrimSuffix(age.String(), "0s") + " ago"
+}
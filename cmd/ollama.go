@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var ollamaCmd = &cobra.Command{
+	Use:   "ollama",
+	Short: "Manage models on the Ollama host",
+	Long: `List, pull and remove models on the configured Ollama host.
+
+Requests to Ollama use the options from the ollama section of config.yml.
+When the selected model has not been pulled yet, it is pulled automatically
+before the request is retried, unless auto_pull is set to false:
+
+  ollama:
+    num_ctx: 8192
+    num_gpu: 99
+    keep_alive: 10m
+    auto_pull: true
+    options:
+      num_thread: 8
+
+Example:
+  suggest ollama ps
+  suggest ollama pull llama3.2
+  suggest ollama rm llama3.2 qwen2.5:7b`,
+}
+
+var ollamaPsCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List the models loaded into memory",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		running, err := newOllamaClient(cfg).Running()
+		if err != nil {
+			fmt.Printf("Error listing running models: %v\n", err)
+			return
+		}
+		if len(running) == 0 {
+			fmt.Println("No models are loaded")
+			return
+		}
+
+		for _, m := range running {
+			fmt.Printf("%s %s\n", cyan(m.Name), faint(formatLoadedModel(m)))
+		}
+	},
+}
+
+var ollamaPullCmd = &cobra.Command{
+	Use:   "pull [model]",
+	Short: "Download a model",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		if err := pullOllamaModel(newOllamaClient(cfg), args[0]); err != nil {
+			fmt.Printf("Error pulling %s: %v\n", args[0], err)
+			return
+		}
+		fmt.Printf("Pulled %s\n", args[0])
+		config.RefreshCatalog(cfg, config.ProviderOllama)
+	},
+}
+
+var ollamaRmCmd = &cobra.Command{
+	Use:   "rm [model...]",
+	Short: "Remove one or more models",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		client := newOllamaClient(cfg)
+		for _, model := range args {
+			if err := client.Delete(model); err != nil {
+				fmt.Printf("Error removing %s: %v\n", model, err)
+				continue
+			}
+			fmt.Printf("Removed %s\n", model)
+		}
+		config.RefreshCatalog(cfg, config.ProviderOllama)
+	},
+}
+
+// pullOllamaModel pulls a model, drawing a progress bar on stderr.
+func pullOllamaModel(client *api.OllamaClient, model string) error {
+	lastStatus := ""
+	err := client.Pull(model, func(p api.PullProgress) {
+		if p.Total > 0 {
+			fmt.Fprintf(os.Stderr, "\r%s", formatPullProgress(p))
+			lastStatus = p.Status
+			return
+		}
+		if p.Status == lastStatus {
+			return
+		}
+		if lastStatus != "" {
+			fmt.Fprintln(os.Stderr)
+		}
+		fmt.Fprint(os.Stderr, p.Status)
+		lastStatus = p.Status
+	})
+	if lastStatus != "" {
+		fmt.Fprintln(os.Stderr)
+	}
+	return err
+}
+
+func formatPullProgress(p api.PullProgress) string {
+	const barWidth = 30
+
+	status := p.Status
+	if p.Digest != "" {
+		// Show a short digest like the ollama CLI does
+		digest := strings.TrimPrefix(p.Digest, "sha256:")
+		status = "pulling " + digest[:min(12, len(digest))]
+	}
+
+	completed := min(p.Completed, p.Total)
+	filled := int(completed * barWidth / p.Total)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	return fmt.Sprintf("%s %3d%% %s %s/%s ", status, completed*100/p.Total, bar,
+		formatBytes(completed), formatBytes(p.Total))
+}
+
+func formatLoadedModel(m api.RunningModel) string {
+	var parts []string
+	if m.Details.ParameterSize != "" {
+		parts = append(parts, m.Details.ParameterSize)
+	}
+	if m.Details.QuantizationLevel != "" {
+		parts = append(parts, m.Details.QuantizationLevel)
+	}
+	parts = append(parts, formatBytes(m.Size))
+
+	switch {
+	case m.SizeVRAM == 0:
+		parts = append(parts, "100% CPU")
+	case m.SizeVRAM >= m.Size:
+		parts = append(parts, "100% GPU")
+	default:
+		gpu := m.SizeVRAM * 100 / m.Size
+		parts = append(parts, fmt.Sprintf("%d%%/%d%% CPU/GPU", 100-gpu, gpu))
+	}
+
+	if !m.ExpiresAt.IsZero() {
+		if until := time.Until(m.ExpiresAt); until > 365*24*time.Hour {
+			parts = append(parts, "kept loaded")
+		} else if until > 0 {
+			parts = append(parts, "unloads in "+until.Round(time.Second).String())
+		} else {
+			parts = append(parts, "unloading")
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func init() {
+	ollamaCmd.AddCommand(ollamaPsCmd)
+	ollamaCmd.AddCommand(ollamaPullCmd)
+	ollamaCmd.AddCommand(ollamaRmCmd)
+	rootCmd.AddCommand(ollamaCmd)
+}
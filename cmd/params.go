@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	temperatureFlag float64
+	maxTokensFlag   int
+	topPFlag        float64
+	seedFlag        int
+	stopFlag        []string
+)
+
+// addGenerationFlags registers the sampling parameter flags on a command.
+func addGenerationFlags(c *cobra.Command) {
+	c.Flags().Float64Var(&temperatureFlag, "temperature", 0, "Sampling temperature (e.g. 0 for deterministic output)")
+	c.Flags().IntVar(&maxTokensFlag, "max-tokens", 0, "Maximum number of tokens to generate")
+	c.Flags().Float64Var(&topPFlag, "top-p", 0, "Nucleus sampling probability mass")
+	c.Flags().IntVar(&seedFlag, "seed", 0, "Random seed for reproducible sampling, where supported")
+	c.Flags().StringArrayVar(&stopFlag, "stop", nil, "Stop sequence (repeat for several)")
+}
+
+// paramsFunc sets the sampling parameters of a request for the model it
+// names, so they can be set again when a fallback model takes over.
+type paramsFunc func(provider config.Provider, req *api.ChatCompletionRequest)
+
+// generationParams returns a paramsFunc that calls applyGenerationParams.
+func generationParams(cmd *cobra.Command, cfg *config.Config, defaultTemperature *float64) paramsFunc {
+	return func(provider config.Provider, req *api.ChatCompletionRequest) {
+		applyGenerationParams(cmd, cfg, provider, req, defaultTemperature)
+	}
+}
+
+// applyGenerationParams sets the sampling parameters of req from, in
+// increasing order of precedence, the command's default temperature, the
+// model's entry in model_params and any flags given on the command line.
+func applyGenerationParams(cmd *cobra.Command, cfg *config.Config, provider config.Provider, req *api.ChatCompletionRequest, defaultTemperature *float64) {
+	req.Temperature = defaultTemperature
+	req.TopP = nil
+	req.MaxTokens = 0
+	req.Seed = nil
+	req.Stop = nil
+
+	params, ok := cfg.ModelParams[string(provider)+"/"+req.Model]
+	if !ok {
+		params, ok = cfg.ModelParams[req.Model]
+	}
+	if ok {
+		if params.Temperature != nil {
+			req.Temperature = params.Temperature
+		}
+		if params.TopP != nil {
+			req.TopP = params.TopP
+		}
+		if params.MaxTokens > 0 {
+			req.MaxTokens = params.MaxTokens
+		}
+		if params.Seed != nil {
+			req.Seed = params.Seed
+		}
+		if len(params.Stop) > 0 {
+			req.Stop = params.Stop
+		}
+	}
+
+	if cmd == nil {
+		return
+	}
+	flags := cmd.Flags()
+	if flags.Changed("temperature") {
+		req.Temperature = api.Float(temperatureFlag)
+	}
+	if flags.Changed("top-p") {
+		req.TopP = api.Float(topPFlag)
+	}
+	if flags.Changed("max-tokens") {
+		req.MaxTokens = maxTokensFlag
+	}
+	if flags.Changed("seed") {
+		seed := seedFlag
+		req.Seed = &seed
+	}
+	if flags.Changed("stop") {
+		req.Stop = stopFlag
+	}
+}
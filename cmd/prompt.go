@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/templating"
+	"github.com/tedfulk/suggest/internal/utils"
+)
+
+var varsFlag string
+
+// readMessage builds the user message from the arguments and any content
+// piped on stdin. An empty message is returned when neither is given.
+func readMessage(args []string) (string, error) {
+	var argMessage string
+
+	pipedContent, err := utils.ReadStdin()
+	if err != nil {
+		return "", err
+	}
+
+	if len(args) > 0 {
+		argMessage = strings.Join(args, " ")
+	}
+
+	// Determine the final message based on input sources
+	if pipedContent != "" && argMessage != "" {
+		// Combine piped content and arguments
+		return fmt.Sprintf("Context provided via pipe:\n---\n%s\n---\n\nUser query based on arguments:\n%s", pipedContent, argMessage), nil
+	} else if pipedContent != "" {
+		return pipedContent, nil
+	}
+	return argMessage, nil
+}
+
+// findTemplate looks up a template by title.
+func findTemplate(cfg *config.Config, title string) (*config.Template, error) {
+	for _, t := range cfg.Templates {
+		if t.Title == title {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("template '%s' not found", title)
+}
+
+// applyTemplate fills in the template with the given title using vars in
+// the "key1=value1,key2=value2" format.
+func applyTemplate(cfg *config.Config, title, vars string) (string, error) {
+	values := map[string]string{}
+	if vars != "" {
+		values = parseTemplateVars(vars)
+	}
+	return renderTemplate(cfg, title, values)
+}
+
+// renderTemplate fills in the template with the given title.
+func renderTemplate(cfg *config.Config, title string, vars map[string]string) (string, error) {
+	selectedTemplate, err := findTemplate(cfg, title)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := templating.Render(selectedTemplate.Content, selectedTemplate.Syntax, vars)
+	if err != nil {
+		return "", fmt.Errorf("template '%s': %w", title, err)
+	}
+	return message, nil
+}
+
+// composeMessage renders the template with the given title, if any, and
+// appends prompt to it.
+func composeMessage(cfg *config.Config, templateTitle string, vars map[string]string, prompt string) (string, error) {
+	if templateTitle == "" {
+		return prompt, nil
+	}
+	message, err := renderTemplate(cfg, templateTitle, vars)
+	if err != nil {
+		return "", err
+	}
+	if prompt != "" {
+		message += "\n\n" + prompt
+	}
+	return message, nil
+}
+
+// resolveSystemPrompt returns the content of the system prompt with the
+// given title, or the active system prompt when title is empty.
+func resolveSystemPrompt(cfg *config.Config, title string) (string, error) {
+	if title == "" {
+		return cfg.SystemPrompt, nil
+	}
+	for _, p := range cfg.SystemPrompts {
+		if p.Title == title {
+			return p.Content, nil
+		}
+	}
+	return "", fmt.Errorf("system prompt '%s' not found", title)
+}
+
+// buildMessages returns the system prompt, if any, followed by the user
+// message.
+func buildMessages(systemPrompt, message string) []api.ChatMessage {
+	messages := []api.ChatMessage{}
+	if systemPrompt != "" {
+		messages = append(messages, api.ChatMessage{
+			Role:    "system",
+			Content: systemPrompt,
+		})
+	}
+	return append(messages, api.ChatMessage{
+		Role:    "user",
+		Content: message,
+	})
+}
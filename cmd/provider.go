@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/cache"
+	"github.com/tedfulk/suggest/internal/config"
+)
+
+var (
+	cacheFlag   bool
+	noCacheFlag bool
+)
+
+// Helper function to get response from the appropriate API, served from the
+// response cache when it is enabled
+func getResponse(provider config.Provider, cfg *config.Config, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+	if !responseCacheEnabled(cfg) || req.Stream {
+		return callProvider(provider, cfg, req)
+	}
+
+	responseCache := newResponseCache(cfg)
+	key, err := cache.Key(string(provider), req)
+	if err != nil {
+		return callProvider(provider, cfg, req)
+	}
+	if resp, ok := responseCache.Get(key); ok {
+		return resp, nil
+	}
+
+	resp, err := callProvider(provider, cfg, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseCache.Put(key, string(provider), resp); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not cache response: %v\n", err)
+	}
+	return resp, nil
+}
+
+// resolvedModel is a model name resolved to its provider and model ID.
+type resolvedModel struct {
+	provider config.Provider
+	model    string
+	err      error
+}
+
+// resolvedModels maps model names to their resolution.
+type resolvedModels map[string]resolvedModel
+
+// resolveModels resolves every distinct name once, before requests fan out,
+// so concurrent workers don't each refresh and rewrite the model catalog.
+func resolveModels(cfg *config.Config, names ...string) resolvedModels {
+	resolved := make(resolvedModels)
+	for _, name := range names {
+		if _, ok := resolved[name]; ok {
+			continue
+		}
+		provider, model, err := config.ResolveModel(name, cfg)
+		resolved[name] = resolvedModel{provider: provider, model: model, err: err}
+	}
+	return resolved
+}
+
+// get returns the resolution of name, which must have been resolved by
+// resolveModels.
+func (r resolvedModels) get(name string) (config.Provider, string, error) {
+	m, ok := r[name]
+	if !ok {
+		return "", "", fmt.Errorf("model '%s' was not resolved", name)
+	}
+	return m.provider, m.model, m.err
+}
+
+// responseCacheEnabled applies the --cache and --no-cache flags on top of
+// the cache setting in the config.
+func responseCacheEnabled(cfg *config.Config) bool {
+	if noCacheFlag {
+		return false
+	}
+	return cacheFlag || cfg.Cache.Enabled
+}
+
+func newResponseCache(cfg *config.Config) *cache.Cache {
+	cacheDir, err := config.CacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return cache.New(filepath.Join(cacheDir, "responses"), config.ResponseCacheTTL(cfg), config.ResponseCacheMaxBytes(cfg))
+}
+
+// callProvider sends the request to the provider's API.
+func callProvider(provider config.Provider, cfg *config.Config, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+	switch provider {
+	case config.ProviderGroq:
+		if cfg.GroqAPIKey == "" {
+			return nil, fmt.Errorf("groq API key not set, use 'suggest keys groq' to set it")
+		}
+		return api.NewGroqClient(cfg.GroqAPIKey).CreateChatCompletion(req)
+	case config.ProviderOpenAI:
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("openai API key not set, use 'suggest keys openai' to set it")
+		}
+		return api.NewOpenAIClient(cfg.OpenAIAPIKey).CreateChatCompletion(req)
+	case config.ProviderGemini:
+		if cfg.GeminiAPIKey == "" {
+			return nil, fmt.Errorf("gemini API key not set, use 'suggest keys gemini' to set it")
+		}
+		return api.NewGeminiClient(cfg.GeminiAPIKey).CreateChatCompletion(req)
+	case config.ProviderOllama:
+		client := newOllamaClient(cfg)
+		resp, err := client.CreateChatCompletion(req)
+		if err != nil && api.IsModelNotFound(err) && config.OllamaAutoPull(cfg) {
+			fmt.Fprintf(os.Stderr, "Model %s is not available locally, pulling it...\n", req.Model)
+			if err := pullOllamaModel(client, req.Model); err != nil {
+				return nil, fmt.Errorf("error pulling %s: %w", req.Model, err)
+			}
+			config.RefreshCatalog(cfg, config.ProviderOllama)
+			return client.CreateChatCompletion(req)
+		}
+		return resp, err
+	default:
+		return nil, fmt.Errorf("model '%s' not supported", req.Model)
+	}
+}
+
+// newOllamaClient creates an Ollama client with the options from the ollama
+// section of the config.
+func newOllamaClient(cfg *config.Config) *api.OllamaClient {
+	client := api.NewOllamaClient(cfg.OllamaHost)
+	client.Options = config.OllamaOptions(cfg)
+	client.KeepAlive = cfg.Ollama.KeepAlive
+	return client
+}
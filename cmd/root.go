@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -50,6 +49,7 @@ var (
 	blue   = color.New(color.FgBlue).SprintFunc()
 	green  = color.New(color.FgGreen).SprintFunc()
 	white  = color.New(color.FgWhite).SprintFunc()
+	faint  = color.New(color.Faint).SprintFunc()
 )
 
 var helpTemplate = `{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces}}
@@ -101,54 +101,23 @@ Example:
   suggest -t "Code Function" --vars "language=Python,task=sort a list"
   suggest -s "Programming Assistant" Write a function
   suggest -e "What are design patterns?"
+  suggest --tools "Which files in this directory mention TODO?"
   suggest chat  # Start an interactive chat session
   cat file.txt | suggest "Summarize this file"
   cat code.py | suggest -s "Programming Assistant" "Review this Python code"`,
 	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		var message string
-		var pipedContent string
-		var argMessage string
-
-		// Check if data is being piped
-		stat, _ := os.Stdin.Stat()
-		isPiped := (stat.Mode() & os.ModeCharDevice) == 0
-
-		if isPiped {
-			bytes, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				fmt.Println("Error reading from stdin:", err)
-				return
-			}
-			pipedContent = strings.TrimSpace(string(bytes))
-		}
-
-		if len(args) > 0 {
-			argMessage = strings.Join(args, " ")
+		message, err := readMessage(args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
-
-		// Determine the final message based on input sources
-		if pipedContent != "" && argMessage != "" {
-			// Combine piped content and arguments
-			message = fmt.Sprintf("Context provided via pipe:\n---\n%s\n---\n\nUser query based on arguments:\n%s", pipedContent, argMessage)
-		} else if pipedContent != "" {
-			// Use only piped content
-			message = pipedContent
-		} else if argMessage != "" {
-			// Use only arguments
-			message = argMessage
-		} else {
+		if strings.TrimSpace(message) == "" && templateFlag == "" {
 			// No input provided
 			fmt.Println("Please provide a message via arguments or pipe content. Use --help for more information.")
 			return
 		}
 
-		// If message is effectively empty after processing, exit.
-		if strings.TrimSpace(message) == "" {
-			fmt.Println("Received empty or whitespace-only input.")
-			return
-		}
-
 		cfg, err := config.LoadConfig()
 		if err != nil {
 			fmt.Println("Error loading config:", err)
@@ -182,40 +151,17 @@ Example:
 		}
 
 		if templateFlag != "" {
-			var selectedTemplate *config.Template
-			for _, t := range cfg.Templates {
-				if t.Title == templateFlag {
-					selectedTemplate = &t
-					break
-				}
-			}
-			if selectedTemplate == nil {
-				fmt.Printf("Template '%s' not found\n", templateFlag)
+			message, err = applyTemplate(cfg, templateFlag, varsFlag)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
 				return
 			}
-			message = selectedTemplate.Content
-			if vars, _ := cmd.Flags().GetString("vars"); vars != "" {
-				varMap := parseTemplateVars(vars)
-				for key, value := range varMap {
-					message = strings.ReplaceAll(message, "["+key+"]", value)
-				}
-			}
 		}
 
-		systemPrompt := cfg.SystemPrompt
-		if systemFlag != "" {
-			var found bool
-			for _, p := range cfg.SystemPrompts {
-				if p.Title == systemFlag {
-					systemPrompt = p.Content
-					found = true
-					break
-				}
-			}
-			if !found {
-				fmt.Printf("System prompt '%s' not found\n", systemFlag)
-				return
-			}
+		systemPrompt, err := resolveSystemPrompt(cfg, systemFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
 		model := cfg.Model
@@ -223,73 +169,30 @@ Example:
 			model = modelFlag
 		}
 
-		if actualModel, exists := cfg.ModelAliases[model]; exists {
-			model = actualModel
+		provider, model, err := config.ResolveModel(model, cfg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
-		messages := []api.ChatMessage{}
-		
-		if systemPrompt != "" {
-			messages = append(messages, api.ChatMessage{
-				Role:    "system",
-				Content: systemPrompt,
-			})
-		}
-		
-		messages = append(messages, api.ChatMessage{
-			Role:    "user",
-			Content: message,
-		})
+		messages := buildMessages(systemPrompt, message)
 
 		req := &api.ChatCompletionRequest{
-			Model:       model,
-			Messages:    messages,
-			Temperature: 0.7,
+			Model:    model,
+			Messages: messages,
 		}
+		params := generationParams(cmd, cfg, api.Float(0.7))
+		params(provider, req)
 
 		var resp *api.ChatCompletionResponse
 		var apiErr error
-
-		provider := config.DetermineModelProvider(model, cfg)
-		if provider == "" {
-			fmt.Printf("Model '%s' not supported. Please use a Groq, OpenAI, Gemini, or Ollama model.\n", model)
-			return
-		}
-
-		switch provider {
-		case "groq":
-			if cfg.GroqAPIKey == "" {
-				fmt.Println("Groq API key not set. Please set it in your config file.")
-				return
-			}
-			client := api.NewGroqClient(cfg.GroqAPIKey)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		case "openai":
-			if cfg.OpenAIAPIKey == "" {
-				fmt.Println("OpenAI API key not set. Please set it in your config file.")
-				return
-			}
-			client := api.NewOpenAIClient(cfg.OpenAIAPIKey)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		case "gemini":
-			if cfg.GeminiAPIKey == "" {
-				fmt.Println("Gemini API key not set. Please set it in your config file.")
-				return
-			}
-			client := api.NewGeminiClient(cfg.GeminiAPIKey)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		case "ollama":
-			client := api.NewOllamaClient(cfg.OllamaHost)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		default:
-			fmt.Printf("Model '%s' not supported. Please use a Groq, OpenAI, Gemini, or Ollama model.\n", model)
-			return
+		if toolsFlag {
+			registry, closeTools := newToolRegistry(cfg)
+			defer closeTools()
+			resp, _, apiErr = completeWithTools(cfg, provider, req, registry, params)
+		} else {
+			resp, _, _, apiErr = completeWithFallback(cfg, provider, req, params)
 		}
-
 		if apiErr != nil {
 			fmt.Printf("Error: %v\n", apiErr)
 			return
@@ -325,8 +228,13 @@ func init() {
 	rootCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Specify the model to use")
 	rootCmd.Flags().StringVarP(&templateFlag, "template", "t", "", "Use a template (format: template-name)")
 	rootCmd.Flags().StringVarP(&systemFlag, "system", "s", "", "Use a specific system prompt by title")
+	rootCmd.Flags().StringVar(&varsFlag, "vars", "", "Variables for the template (format: key1=value1,key2=value2)")
 	rootCmd.Flags().BoolVarP(&enhanceFlag, "enhance", "e", false, "Enhance the prompt before processing")
+	rootCmd.Flags().BoolVar(&toolsFlag, "tools", false, "Let the model call local tools and the tools of configured MCP servers")
 	rootCmd.Flags().BoolP("version", "v", false, "Print version information")
+	addGenerationFlags(rootCmd)
+	rootCmd.PersistentFlags().BoolVar(&cacheFlag, "cache", false, "Serve identical requests from the response cache")
+	rootCmd.PersistentFlags().BoolVar(&noCacheFlag, "no-cache", false, "Bypass the response cache")
 
 	cobra.AddTemplateFunc("cyan", cyan)
 	cobra.AddTemplateFunc("yellow", yellow)
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/safety"
+
+	"github.com/manifoldco/promptui"
+)
+
+// confirmCommandRisk analyzes a shell command, shows what makes it risky and
+// asks for the confirmation its risk calls for: none for safe commands
+// unless always is set, y/N for medium risk and the typed word "yes" for
+// high risk. Commands matching a deny rule are refused. It reports whether
+// the command may run.
+func confirmCommandRisk(cfg *config.Config, command string, always bool) bool {
+	analyzer, err := safety.NewAnalyzer(cfg.CommandSafety.Allow, cfg.CommandSafety.Deny)
+	if err != nil {
+		fmt.Printf("%s %v\n", red("Error in command_safety:"), err)
+		return false
+	}
+	dir, _ := os.Getwd()
+	result := analyzer.Analyze(command, dir)
+
+	if result.Denied != "" {
+		fmt.Printf("%s the command matches the deny rule %s\n", red("Blocked:"), result.Denied)
+		return false
+	}
+
+	switch result.Level {
+	case safety.High:
+		fmt.Println(red("⚠ High-risk command:"))
+		printFindings(result.Findings)
+		prompt := promptui.Prompt{
+			Label: "Type 'yes' to run it anyway",
+		}
+		answer, err := prompt.Run()
+		return err == nil && strings.TrimSpace(answer) == "yes"
+
+	case safety.Medium:
+		fmt.Println(yellow("Caution:"))
+		printFindings(result.Findings)
+		always = true
+
+	case safety.Low:
+		printFindings(result.Findings)
+	}
+
+	if !always {
+		return true
+	}
+	prompt := promptui.Prompt{
+		Label:     "Run this command",
+		IsConfirm: true,
+	}
+	_, err = prompt.Run()
+	return err == nil
+}
+
+func printFindings(findings []safety.Finding) {
+	for _, f := range findings {
+		line := fmt.Sprintf("  • %s", f.Reason)
+		switch f.Level {
+		case safety.High:
+			line = red(line)
+		case safety.Medium:
+			line = yellow(line)
+		default:
+			line = faint(line)
+		}
+		fmt.Println(line)
+	}
+}
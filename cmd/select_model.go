@@ -1,23 +1,45 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/tedfulk/suggest/internal/config"
 
-	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 )
 
+// modelItem is a single entry of the model picker.
+type modelItem struct {
+	// Name is what gets stored in the config: the plain model ID, or
+	// "provider/id" when several providers offer the same ID
+	Name         string
+	ID           string
+	Provider     config.Provider
+	ProviderName string
+	Context      string
+	Capabilities string
+	Aliases      string
+	Current      bool
+}
+
 var selectModelCmd = &cobra.Command{
-	Use:   "model",
+	Use:   "model [partial name]",
 	Short: "Interactively select a model to use",
+	Long: `Select the default model from every configured provider.
+
+Without arguments a searchable picker is shown; type to filter by model,
+provider or alias. With a partial name the model is set directly when it
+matches exactly one model, otherwise the picker opens with the matches.
+
+Example:
+  suggest model
+  suggest model 70b
+  suggest model groq/llama-3.3`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.LoadConfig()
 		if err != nil {
@@ -25,164 +47,212 @@ var selectModelCmd = &cobra.Command{
 			return
 		}
 
-		// First, select the provider
-		providers := []string{"OpenAI", "Groq", "Gemini", "Ollama", "Exit"}
-
-		providerPrompt := promptui.Select{
-			Label: "Select Provider",
-			Items: providers,
-			Templates: &promptui.SelectTemplates{
-				Label:    "{{ . }}",
-				Active:   "\U0001F449 {{ . | cyan }}", 
-				Inactive: "  {{ . | white }}",
-				Selected: "\U00002705 {{ . | green }}", 
-			},
-		}
-
-		_, provider, err := providerPrompt.Run()
+		catalog, err := config.GetCatalog(cfg, false)
 		if err != nil {
-			fmt.Printf("Prompt failed %v\n", err)
+			fmt.Println("Error loading model catalog:", err)
 			return
 		}
 
-		if provider == "Exit" {
+		items := buildModelItems(cfg, catalog)
+		if len(items) == 0 {
+			fmt.Println("No models available. Set an API key with 'suggest keys <provider>' or pull a model with 'ollama pull <model>'")
 			return
 		}
 
-		// Check if API key is set for the selected provider
-		var apiKey string
-		var providerType config.Provider
-		switch provider {
-		case "OpenAI":
-			apiKey = cfg.OpenAIAPIKey
-			providerType = config.ProviderOpenAI
-		case "Groq":
-			apiKey = cfg.GroqAPIKey
-			providerType = config.ProviderGroq
-		case "Gemini":
-			apiKey = cfg.GeminiAPIKey
-			providerType = config.ProviderGemini
-		case "Ollama":
-			// No API key needed for Ollama, just check the host
-			if cfg.OllamaHost == "" {
-				cfg.OllamaHost = "http://localhost:11434" // Set default if not configured
-			}
-			providerType = config.ProviderOllama
-		}
-
-		// If no API key is set and it's not Ollama, prompt the user to enter one
-		if apiKey == "" && provider != "Ollama" {
-			fmt.Printf("\nNo API key set for %s. Please enter your API key: ", provider)
-			scanner := bufio.NewScanner(os.Stdin)
-			if scanner.Scan() {
-				apiKey = scanner.Text()
-			}
-			if apiKey == "" {
-				fmt.Println("API key is required to list models")
+		if len(args) == 1 {
+			matches := matchModelItems(items, args[0])
+			switch len(matches) {
+			case 0:
+				fmt.Printf("No model matches '%s'\n", args[0])
 				return
-			}
-
-			// Update the config with the new API key
-			switch provider {
-			case "OpenAI":
-				cfg.OpenAIAPIKey = apiKey
-			case "Groq":
-				cfg.GroqAPIKey = apiKey
-			case "Gemini":
-				cfg.GeminiAPIKey = apiKey
-			}
-
-			err = config.SaveConfig(cfg)
-			if err != nil {
-				fmt.Printf("Error saving config: %v\n", err)
+			case 1:
+				setModel(cfg, matches[0])
 				return
+			default:
+				fmt.Printf("%d models match '%s'\n", len(matches), args[0])
+				items = matches
 			}
-			fmt.Printf("%s API key updated\n", provider)
 		}
 
-		fmt.Printf("\nFetching %s models...\n", provider)
-		models, err := config.FetchModels(providerType, cfg)
-		if err != nil {
-			fmt.Printf("Error fetching models: %v\n", err)
-			return
+		funcMap := template.FuncMap{}
+		for k, v := range promptui.FuncMap {
+			funcMap[k] = v
 		}
+		funcMap["faint"] = faint
 
-		if len(models) == 0 {
-			if provider == "Ollama" {
-				fmt.Println("No Ollama models found. Please pull some models first using 'ollama pull <model>'")
-			} else {
-				fmt.Printf("No models available for %s\n", provider)
+		cursor := 0
+		for i, item := range items {
+			if item.Current {
+				cursor = i
+				break
 			}
-			return
-		}
-
-		// Add aliases that match the selected provider
-		var modelList []string
-		modelList = append(modelList, models...)
-		
-		var aliasList []string
-		for alias, model := range cfg.ModelAliases {
-			if config.DetermineModelProvider(model, cfg) == string(providerType) {
-				aliasList = append(aliasList, fmt.Sprintf("%s (alias for %s)", alias, model))
-			}
-		}
-		sort.Strings(aliasList)
-		modelList = append(modelList, aliasList...)
-
-		cyan := color.New(color.FgCyan).SprintFunc()
-		white := color.New(color.FgWhite).SprintFunc()
-		green := color.New(color.FgGreen).SprintFunc()
-		faint := color.New(color.Faint).SprintFunc()
-
-		funcMap := template.FuncMap{
-			"hasPrefix": strings.HasPrefix,
-			"cyan":     cyan,
-			"white":    white,
-			"green":    green,
-			"faint":    faint,
 		}
 
 		modelPrompt := promptui.Select{
-			Label: "Select Model",
-			Items: modelList,
-			Size:  20,
+			Label:             "Select Model",
+			Items:             items,
+			Size:              20,
+			CursorPos:         cursor,
+			StartInSearchMode: len(args) == 0,
+			Searcher: func(input string, index int) bool {
+				return fuzzyMatch(items[index].searchText(), input)
+			},
 			Templates: &promptui.SelectTemplates{
 				Label:    "{{ . }}",
-				Active:   "\U0001F449 {{ . | cyan }}", 
-				Inactive: "  {{ . | white }}",
-				Selected: "\U00002705 {{ . | green }}", 
+				Active:   "\U0001F449 {{ .ID | cyan }} {{ .ProviderName | faint }}{{ if .Current }} {{ \"(current)\" | green }}{{ end }}",
+				Inactive: "  {{ .ID | white }} {{ .ProviderName | faint }}{{ if .Current }} {{ \"(current)\" | green }}{{ end }}",
+				Selected: "\U00002705 {{ .ID | green }}",
 				FuncMap:  funcMap,
 				Details: `
-{{ "Provider:" | faint }}	{{ if hasPrefix . "gpt-" }}OpenAI{{ else if or (hasPrefix . "mixtral-") (hasPrefix . "llama-") }}Groq{{ else if hasPrefix . "gemini-" }}Gemini{{ else }}Ollama{{ end }}
-{{ "Current:" | faint }}	{{ if eq . $.Model }}Yes{{ else }}No{{ end }}`,
+{{ "Provider:" | faint }}	{{ .ProviderName }}
+{{ "Context:" | faint }}	{{ if .Context }}{{ .Context }}{{ else }}unknown{{ end }}
+{{ "Capabilities:" | faint }}	{{ if .Capabilities }}{{ .Capabilities }}{{ else }}-{{ end }}
+{{ "Aliases:" | faint }}	{{ if .Aliases }}{{ .Aliases }}{{ else }}-{{ end }}
+{{ "Current:" | faint }}	{{ if .Current }}Yes{{ else }}No{{ end }}`,
 			},
 		}
 
-		_, result, err := modelPrompt.Run()
+		idx, _, err := modelPrompt.Run()
 		if err != nil {
 			fmt.Printf("Prompt failed %v\n", err)
 			return
 		}
 
-		model := result
-		if strings.HasSuffix(result, ")") {
-			parts := strings.Split(result, " (alias for ")
-			if len(parts) == 2 {
-				model = strings.TrimSuffix(parts[1], ")")
+		setModel(cfg, items[idx])
+	},
+}
+
+// buildModelItems lists every cached model of the configured providers.
+func buildModelItems(cfg *config.Config, catalog *config.Catalog) []modelItem {
+	currentProvider, currentID, err := config.ResolveModel(cfg.Model, cfg)
+	if err != nil {
+		currentProvider, currentID = "", ""
+	}
+
+	aliases := make(map[string][]string)
+	for alias, model := range cfg.ModelAliases {
+		aliases[model] = append(aliases[model], alias)
+	}
+
+	counts := make(map[string]int)
+	providers := config.ConfiguredProviders(cfg)
+	for _, provider := range providers {
+		for _, m := range catalog.Models(provider) {
+			counts[m.ID]++
+		}
+	}
+
+	var items []modelItem
+	for _, provider := range providers {
+		for _, m := range catalog.Models(provider) {
+			qualified := fmt.Sprintf("%s/%s", provider, m.ID)
+			// IDs offered by several providers, or with a slash that could be
+			// read as a provider prefix, are saved with their provider
+			name := m.ID
+			if counts[m.ID] > 1 || strings.Contains(m.ID, "/") {
+				name = qualified
+			}
+
+			var modelAliases []string
+			modelAliases = append(modelAliases, aliases[m.ID]...)
+			modelAliases = append(modelAliases, aliases[qualified]...)
+			sort.Strings(modelAliases)
+
+			var capabilities []string
+			if m.Vision {
+				capabilities = append(capabilities, "vision")
+			}
+			if m.Tools {
+				capabilities = append(capabilities, "tools")
 			}
+			if m.InputPrice > 0 || m.OutputPrice > 0 {
+				capabilities = append(capabilities, fmt.Sprintf("$%.2f/$%.2f per 1M tokens", m.InputPrice, m.OutputPrice))
+			}
+
+			item := modelItem{
+				Name:         name,
+				ID:           m.ID,
+				Provider:     provider,
+				ProviderName: providerNames[provider],
+				Capabilities: strings.Join(capabilities, ", "),
+				Aliases:      strings.Join(modelAliases, ", "),
+				Current:      provider == currentProvider && m.ID == currentID,
+			}
+			if m.ContextLength > 0 {
+				item.Context = formatTokens(m.ContextLength) + " tokens"
+			}
+			items = append(items, item)
 		}
+	}
+	return items
+}
 
-		cfg.Model = model
-		err = config.SaveConfig(cfg)
-		if err != nil {
-			fmt.Printf("Error saving config: %v\n", err)
-			return
+// matchModelItems finds the models matching a partial name. An exact match
+// on a model ID, "provider/id" or alias wins; otherwise substring matches are
+// preferred over fuzzy ones.
+func matchModelItems(items []modelItem, query string) []modelItem {
+	query = strings.ToLower(query)
+
+	var exact, substring, fuzzy []modelItem
+	for _, item := range items {
+		qualified := strings.ToLower(string(item.Provider) + "/" + item.ID)
+		isAlias := false
+		for _, alias := range strings.Split(item.Aliases, ", ") {
+			if strings.ToLower(alias) == query {
+				isAlias = true
+			}
 		}
 
-		fmt.Printf("Model set to: %s\n", model)
-	},
+		switch {
+		case strings.ToLower(item.ID) == query || qualified == query || isAlias:
+			exact = append(exact, item)
+		case strings.Contains(item.searchText(), query):
+			substring = append(substring, item)
+		case fuzzyMatch(item.searchText(), query):
+			fuzzy = append(fuzzy, item)
+		}
+	}
+
+	if len(exact) > 0 {
+		return exact
+	}
+	if len(substring) > 0 {
+		return substring
+	}
+	return fuzzy
+}
+
+func (m modelItem) searchText() string {
+	return strings.ToLower(fmt.Sprintf("%s/%s %s", m.Provider, m.ID, m.Aliases))
+}
+
+// fuzzyMatch reports whether every whitespace-separated term of input
+// appears in text as an in-order subsequence of characters.
+func fuzzyMatch(text, input string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(input)) {
+		pos := 0
+		for _, r := range term {
+			idx := strings.IndexRune(text[pos:], r)
+			if idx < 0 {
+				return false
+			}
+			pos += idx + len(string(r))
+		}
+	}
+	return true
+}
+
+func setModel(cfg *config.Config, item modelItem) {
+	cfg.Model = item.Name
+	if err := config.SaveConfig(cfg); err != nil {
+		fmt.Printf("Error saving config: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Model set to: %s (%s)\n", item.Name, item.ProviderName)
 }
 
 func init() {
 	rootCmd.AddCommand(selectModelCmd)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,535 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr  string
+	serveToken string
+)
+
+// maxServeRequestBytes bounds the size of a request body.
+const maxServeRequestBytes = 32 * 1024 * 1024
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an OpenAI-compatible HTTP server backed by your configured providers",
+	Long: `Run a local HTTP server that speaks the OpenAI chat completions API and
+routes every request to the provider serving the requested model, so tools
+that only talk to OpenAI can use Groq, Gemini or Ollama models.
+
+Models are resolved like on the command line: aliases, plain model IDs and
+"provider/model" names all work.
+
+Endpoints:
+  POST /v1/chat/completions   Chat completions, with "stream": true support
+  GET  /v1/models             Models of the configured providers and aliases
+
+Requests for OpenAI and Groq models are relayed as is. Requests for Gemini
+and Ollama models are translated; Ollama answers are streamed as they are
+generated. Message content may be a string or an array of text parts, which
+are joined; other parts such as images only work with OpenAI and Groq.
+
+Set --token, or the SUGGEST_SERVE_TOKEN environment variable, to require
+"Authorization: Bearer <token>" on every request.
+
+Example:
+  suggest serve
+  suggest serve --addr 127.0.0.1:8080 --token secret
+  curl localhost:8080/v1/chat/completions -d '{"model":"gemini-1.5-pro","messages":[{"role":"user","content":"Hi"}]}'
+  OPENAI_BASE_URL=http://localhost:8080/v1 OPENAI_API_KEY=secret some-openai-tool`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		token := serveToken
+		if token == "" {
+			token = os.Getenv("SUGGEST_SERVE_TOKEN")
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+			serveChatCompletions(cfg, w, r)
+		})
+		mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+			serveModels(cfg, w, r)
+		})
+
+		auth := "no authentication"
+		if token != "" {
+			auth = "bearer token required"
+		}
+		fmt.Fprintf(os.Stderr, "Serving the OpenAI API on %s (%s)\n", cyan(serveAddr), auth)
+
+		server := &http.Server{
+			Addr:              serveAddr,
+			Handler:           requireToken(token, mux),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		if err := server.ListenAndServe(); err != nil {
+			fmt.Println("Error:", err)
+		}
+	},
+}
+
+// requireToken rejects requests without the bearer token, unless token is
+// empty.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "invalid or missing bearer token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveRequest is a chat completion request as clients send it. Message
+// content may be a string or an array of content parts.
+type serveRequest struct {
+	api.ChatCompletionRequest
+	Messages []serveMessage `json:"messages"`
+}
+
+type serveMessage struct {
+	api.ChatMessage
+	Content json.RawMessage `json:"content"`
+}
+
+// contentPart is an element of an array message content.
+type contentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func serveChatCompletions(cfg *config.Config, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "use POST")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxServeRequestBytes))
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	var wire serveRequest
+	if err := json.Unmarshal(body, &wire); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON: "+err.Error())
+		return
+	}
+
+	requested := wire.Model
+	if requested == "" {
+		requested = cfg.Model
+	}
+	provider, model, err := config.ResolveModel(requested, cfg)
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", err.Error())
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %s/%s\n", faint(time.Now().Format("15:04:05")), provider, model)
+
+	if provider == config.ProviderOpenAI || provider == config.ProviderGroq {
+		relayChatCompletion(cfg, provider, model, body, w)
+		return
+	}
+
+	// Other providers take text, so content parts are flattened
+	req := wire.ChatCompletionRequest
+	req.Model = model
+	req.Messages = make([]api.ChatMessage, len(wire.Messages))
+	for i, m := range wire.Messages {
+		req.Messages[i] = m.ChatMessage
+		if req.Messages[i].Content, err = contentText(m.Content); err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("messages[%d]: %v", i, err))
+			return
+		}
+	}
+
+	if provider == config.ProviderOllama && req.Stream {
+		streamOllamaCompletion(cfg, &req, w)
+		return
+	}
+
+	stream := req.Stream
+	req.Stream = false
+	resp, _, _, err := completeWithFallback(cfg, provider, &req, nil)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	if resp.ID == "" {
+		resp.ID = newCompletionID()
+	}
+	if resp.Created == 0 {
+		resp.Created = time.Now().Unix()
+	}
+
+	if !stream {
+		writeJSON(w, http.StatusOK, toOpenAIResponse(resp))
+		return
+	}
+
+	// Providers without streaming support answer in one chunk
+	sse := newSSEWriter(w, resp.ID, resp.Model)
+	for _, choice := range resp.Choices {
+		sse.content(choice.Message.Content)
+		sse.finish(choice.Message.ToolCalls)
+	}
+	sse.done()
+}
+
+// contentText returns the text of a message content, which is a string, an
+// array of content parts or null. Text parts are joined by newlines; other
+// parts, such as images, are refused.
+func contentText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	var parts []contentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", errors.New("content must be a string or an array of content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type != "text" {
+			return "", fmt.Errorf("content parts of type %q are not supported for this model", part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// relayChatCompletion forwards the original request body, with the resolved
+// model ID, to an OpenAI-compatible provider and copies its answer back,
+// streaming or not.
+func relayChatCompletion(cfg *config.Config, provider config.Provider, model string, body []byte, w http.ResponseWriter) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON: "+err.Error())
+		return
+	}
+	raw["model"], _ = json.Marshal(model)
+	body, err := json.Marshal(raw)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	var resp *http.Response
+	switch provider {
+	case config.ProviderOpenAI:
+		if cfg.OpenAIAPIKey == "" {
+			writeOpenAIError(w, http.StatusBadGateway, "server_error", "openai API key not set, use 'suggest keys openai' to set it")
+			return
+		}
+		resp, err = api.NewOpenAIClient(cfg.OpenAIAPIKey).Forward(body)
+	case config.ProviderGroq:
+		if cfg.GroqAPIKey == "" {
+			writeOpenAIError(w, http.StatusBadGateway, "server_error", "groq API key not set, use 'suggest keys groq' to set it")
+			return
+		}
+		resp, err = api.NewGroqClient(cfg.GroqAPIKey).Forward(body)
+	}
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "server_error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, h := range []string{"Content-Type", "Cache-Control"} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	// Flush as data arrives so event streams reach the client promptly
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// streamOllamaCompletion streams an Ollama answer as OpenAI chunks while it
+// is generated.
+func streamOllamaCompletion(cfg *config.Config, req *api.ChatCompletionRequest, w http.ResponseWriter) {
+	var sse *sseWriter
+	onContent := func(content string) {
+		if sse == nil {
+			sse = newSSEWriter(w, newCompletionID(), req.Model)
+		}
+		sse.content(content)
+	}
+
+	client := newOllamaClient(cfg)
+	resp, err := client.CreateChatCompletionStream(req, onContent)
+	if err != nil && api.IsModelNotFound(err) && config.OllamaAutoPull(cfg) {
+		fmt.Fprintf(os.Stderr, "Model %s is not available locally, pulling it...\n", req.Model)
+		if err = pullOllamaModel(client, req.Model); err == nil {
+			config.RefreshCatalog(cfg, config.ProviderOllama)
+			resp, err = client.CreateChatCompletionStream(req, onContent)
+		}
+	}
+	if err != nil {
+		if sse == nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		// The status line has been sent, so the stream just ends
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		sse.done()
+		return
+	}
+
+	if sse == nil {
+		sse = newSSEWriter(w, newCompletionID(), req.Model)
+	}
+	var toolCalls []api.ToolCall
+	if len(resp.Choices) > 0 {
+		toolCalls = resp.Choices[0].Message.ToolCalls
+	}
+	sse.finish(toolCalls)
+	sse.done()
+}
+
+// sseWriter writes OpenAI chat completion chunks as server-sent events.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	id      string
+	model   string
+	created int64
+	started bool
+}
+
+func newSSEWriter(w http.ResponseWriter, id, model string) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	return &sseWriter{w: w, flusher: flusher, id: id, model: model, created: time.Now().Unix()}
+}
+
+func (s *sseWriter) send(delta map[string]interface{}, finishReason interface{}) {
+	chunk := map[string]interface{}{
+		"id":      s.id,
+		"object":  "chat.completion.chunk",
+		"created": s.created,
+		"model":   s.model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"delta":         delta,
+			"finish_reason": finishReason,
+		}},
+	}
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.w, "data: %s\n\n", data)
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+func (s *sseWriter) content(content string) {
+	delta := map[string]interface{}{"content": content}
+	if !s.started {
+		delta["role"] = "assistant"
+		s.started = true
+	}
+	s.send(delta, nil)
+}
+
+func (s *sseWriter) finish(toolCalls []api.ToolCall) {
+	if len(toolCalls) == 0 {
+		s.send(map[string]interface{}{}, "stop")
+		return
+	}
+
+	var calls []map[string]interface{}
+	for i, call := range toolCalls {
+		calls = append(calls, map[string]interface{}{
+			"index":    i,
+			"id":       call.ID,
+			"type":     "function",
+			"function": call.Function,
+		})
+	}
+	delta := map[string]interface{}{"tool_calls": calls}
+	if !s.started {
+		delta["role"] = "assistant"
+		s.started = true
+	}
+	s.send(delta, nil)
+	s.send(map[string]interface{}{}, "tool_calls")
+}
+
+func (s *sseWriter) done() {
+	fmt.Fprint(s.w, "data: [DONE]\n\n")
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// openAIChoice adds the finish reason OpenAI clients expect.
+type openAIChoice struct {
+	Index        int             `json:"index"`
+	Message      api.ChatMessage `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+}
+
+type openAIResponse struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   interface{}    `json:"usage"`
+}
+
+func toOpenAIResponse(resp *api.ChatCompletionResponse) openAIResponse {
+	out := openAIResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: resp.Created,
+		Model:   resp.Model,
+		Choices: []openAIChoice{},
+		Usage:   resp.Usage,
+	}
+	for _, c := range resp.Choices {
+		reason := "stop"
+		if len(c.Message.ToolCalls) > 0 {
+			reason = "tool_calls"
+		}
+		out.Choices = append(out.Choices, openAIChoice{
+			Index:        c.Index,
+			Message:      c.Message,
+			FinishReason: reason,
+		})
+	}
+	return out
+}
+
+func serveModels(cfg *config.Config, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "use GET")
+		return
+	}
+
+	catalog, err := config.GetCatalog(cfg, false)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+	models := []model{}
+	for _, item := range buildModelItems(cfg, catalog) {
+		var created int64
+		if entry := catalog.Providers[item.Provider]; entry != nil {
+			created = entry.FetchedAt.Unix()
+		}
+		models = append(models, model{ID: item.Name, Object: "model", Created: created, OwnedBy: string(item.Provider)})
+	}
+
+	var aliases []string
+	for alias := range cfg.ModelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		models = append(models, model{ID: alias, Object: "model", OwnedBy: "alias"})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   models,
+	})
+}
+
+// writeUpstreamError passes on the status of a failed provider request.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		writeOpenAIError(w, apiErr.StatusCode, "upstream_error", apiErr.Body)
+		return
+	}
+	writeOpenAIError(w, http.StatusBadGateway, "server_error", err.Error())
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    errType,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newCompletionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Require this bearer token on every request")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var shellInitKey string
+
+const bashWidget = `# suggest: press Ctrl+%[2]s to turn the description on the command line into a command
+__suggest_cmd() {
+  [ -z "$READLINE_LINE" ] && return
+  local cmd
+  cmd=$(suggest cmd --print --shell bash -- "$READLINE_LINE") || return
+  if [ -n "$cmd" ]; then
+    READLINE_LINE=$cmd
+    READLINE_POINT=${#READLINE_LINE}
+  fi
+}
+bind -x '"\C-%[1]s": __suggest_cmd'
+`
+
+const zshWidget = `# suggest: press Ctrl+%[2]s to turn the description on the command line into a command
+_suggest_cmd() {
+  [[ -z $BUFFER ]] && return
+  local cmd
+  zle -R "Asking suggest..."
+  cmd=$(suggest cmd --print --shell zsh -- "$BUFFER") || { zle reset-prompt; return; }
+  if [[ -n $cmd ]]; then
+    BUFFER=$cmd
+    CURSOR=${#BUFFER}
+  fi
+  zle reset-prompt
+}
+zle -N _suggest_cmd
+bindkey '^%[2]s' _suggest_cmd
+`
+
+const fishWidget = `# suggest: press Ctrl+%[2]s to turn the description on the command line into a command
+function __suggest_cmd
+    set -l line (commandline)
+    if test -z "$line"
+        return
+    end
+    set -l cmd (suggest cmd --print --shell fish -- "$line" | string collect)
+    if test $status -eq 0 -a -n "$cmd"
+        commandline -r -- $cmd
+    end
+    commandline -f repaint
+end
+bind \c%[1]s __suggest_cmd
+`
+
+var shellInitCmd = &cobra.Command{
+	Use:       "shell-init bash|zsh|fish",
+	Short:     "Print a shell widget that turns the command line into a suggested command",
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Long: `Print a key binding for your shell. Type a description of what you want at
+the prompt, press the key, and the description is replaced with a suggested
+command, ready to review and run. Nothing is run for you.
+
+The default key is Ctrl+G; pick another letter with --key.
+
+Add one of these to your shell's startup file:
+  eval "$(suggest shell-init bash)"    # ~/.bashrc
+  eval "$(suggest shell-init zsh)"     # ~/.zshrc
+  suggest shell-init fish | source     # ~/.config/fish/config.fish`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := strings.ToLower(shellInitKey)
+		if len(key) != 1 || key[0] < 'a' || key[0] > 'z' {
+			fmt.Println("The key must be a single letter, used with Ctrl")
+			return
+		}
+
+		var widget string
+		switch args[0] {
+		case "bash":
+			widget = bashWidget
+		case "zsh":
+			widget = zshWidget
+		case "fish":
+			widget = fishWidget
+		default:
+			fmt.Printf("Unsupported shell '%s'. Use bash, zsh or fish\n", args[0])
+			return
+		}
+		fmt.Printf(widget, key, strings.ToUpper(key))
+	},
+}
+
+func init() {
+	shellInitCmd.Flags().StringVar(&shellInitKey, "key", "g", "Letter to bind, pressed with Ctrl")
+	rootCmd.AddCommand(shellInitCmd)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/tedfulk/suggest/internal/config"
 	"text/template"
 
+	"github.com/tedfulk/suggest/internal/templating"
 	"github.com/tedfulk/suggest/internal/utils"
 
 	"github.com/fatih/color"
@@ -20,8 +21,21 @@ var templateCmd = &cobra.Command{
 	Short: "Manage message templates",
 	Long: `Manage message templates for common patterns.
 	
+Templates use [variable] placeholders. Templates added with --syntax go are
+Go text/templates instead, with defaults, conditionals and helpers:
+
+  {{.language | default "Go"}}    a variable with a default
+  {{if .tests}}...{{end}}         a conditional
+  {{file "notes.md"}}             the contents of a file
+  {{env "USER"}}                  an environment variable
+  {{shell "git diff --staged"}}   the output of a command
+  {{date}} or {{date "Jan 2"}}    today's date, optionally in a Go layout
+  {{stdin}}                       content piped into suggest
+
 Example:
   suggest template add code "Write a [language] function that [task]"
+  suggest template add --syntax go review 'Review this {{.language | default "Go"}} diff:
+{{shell "git diff --staged"}}'
   suggest template use code --vars "language=Python,task=sorts a list"
   suggest template remove code
   suggest template list`,
@@ -36,7 +50,9 @@ var templateAddCmd = &cobra.Command{
    suggest template add
 
 2. Direct mode:
-   suggest template add "Code Function" "Write a [language] function that [task]"`,
+   suggest template add "Code Function" "Write a [language] function that [task]"
+
+Pass --syntax go for a Go text/template; see 'suggest template --help'.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.LoadConfig()
 		if err != nil {
@@ -53,7 +69,11 @@ var templateAddCmd = &cobra.Command{
 				title = scanner.Text()
 			}
 
-			fmt.Println("Enter template content (use [variable] for placeholders, Shift+Enter for new line, Enter to finish):")
+			placeholder := "[variable]"
+			if templateSyntax == templating.SyntaxGo {
+				placeholder = "{{.variable}}"
+			}
+			fmt.Printf("Enter template content (use %s for placeholders, Shift+Enter for new line, Enter to finish):\n", placeholder)
 			var lines []string
 			reader := bufio.NewReader(os.Stdin)
 			for {
@@ -90,9 +110,19 @@ var templateAddCmd = &cobra.Command{
 			}
 		}
 
+		if err := templating.Validate(content, templateSyntax); err != nil {
+			fmt.Println("Invalid template:", err)
+			return
+		}
+		syntax := templateSyntax
+		if syntax == templating.SyntaxBrackets {
+			syntax = ""
+		}
+
 		cfg.Templates = append(cfg.Templates, config.Template{
 			Title:   title,
 			Content: content,
+			Syntax:  syntax,
 		})
 
 		err = config.SaveConfig(cfg)
@@ -103,7 +133,7 @@ var templateAddCmd = &cobra.Command{
 
 		fmt.Printf("Template '%s' added\n", title)
 
-		vars := utils.ExtractVariables(content)
+		vars := templating.Variables(content, syntax)
 		if len(vars) > 0 {
 			fmt.Println("\nTemplate variables:")
 			for _, v := range vars {
@@ -161,7 +191,9 @@ var templateRemoveCmd = &cobra.Command{
 				"white":    white,
 				"red":      red,
 				"faint":    faint,
-				"truncate": utils.TruncateText,
+				"truncate": func(length int, text string) string {
+					return utils.TruncateText(text, length)
+				},
 			}
 
 			prompt := promptui.Select{
@@ -250,13 +282,18 @@ var templateListCmd = &cobra.Command{
 
 		fmt.Println("Available templates:")
 		for _, template := range cfg.Templates {
-			vars := utils.ExtractVariables(template.Content)
+			vars := templating.Variables(template.Content, template.Syntax)
 			content := template.Content
-			for _, v := range vars {
-				content = strings.ReplaceAll(content, "["+v+"]", yellow("["+v+"]"))
+			title := cyan(template.Title)
+			if template.Syntax == templating.SyntaxGo {
+				title += " " + faint("(go)")
+			} else {
+				for _, v := range vars {
+					content = strings.ReplaceAll(content, "["+v+"]", yellow("["+v+"]"))
+				}
 			}
 
-			fmt.Printf("  %s: %s\n", cyan(template.Title), content)
+			fmt.Printf("  %s: %s\n", title, content)
 
 			if len(vars) > 0 {
 				fmt.Printf("    %s %s\n", faint("Variables:"), yellow(strings.Join(vars, ", ")))
@@ -266,7 +303,8 @@ var templateListCmd = &cobra.Command{
 }
 
 var (
-	templateVars string
+	templateVars   string
+	templateSyntax string
 )
 
 var templateSelectCmd = &cobra.Command{
@@ -304,9 +342,15 @@ var templateSelectCmd = &cobra.Command{
 				"green":    green,
 				"faint":    faint,
 				"yellow":   yellow,
-				"truncate": utils.TruncateText,
-				"extractVariables": utils.ExtractVariables,
-				"join":     strings.Join,
+				"truncate": func(length int, text string) string {
+					return utils.TruncateText(text, length)
+				},
+				"variables": func(t config.Template) []string {
+					return templating.Variables(t.Content, t.Syntax)
+				},
+				"join": func(sep string, elems []string) string {
+					return strings.Join(elems, sep)
+				},
 			}
 
 			prompt := promptui.Select{
@@ -321,7 +365,7 @@ var templateSelectCmd = &cobra.Command{
 					Details: `
 {{ "Title:" | faint }}	{{ .Title }}
 {{ "Content:" | faint }}	{{ .Content | truncate 100 | faint }}
-{{ "Variables:" | faint }}	{{ extractVariables .Content | join ", " | yellow }}`,
+{{ "Variables:" | faint }}	{{ variables . | join ", " | yellow }}`,
 					FuncMap: funcMap,
 				},
 			}
@@ -347,12 +391,10 @@ var templateSelectCmd = &cobra.Command{
 			}
 		}
 
-		message := selectedTemplate.Content
-		if templateVars != "" {
-			vars := parseTemplateVars(templateVars)
-			for key, value := range vars {
-				message = strings.ReplaceAll(message, "["+key+"]", value)
-			}
+		message, err := applyTemplate(cfg, selectedTemplate.Title, templateVars)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
 		rootCmd.SetArgs(strings.Fields(message))
@@ -374,6 +416,7 @@ func parseTemplateVars(vars string) map[string]string {
 
 func init() {
 	templateSelectCmd.Flags().StringVarP(&templateVars, "vars", "v", "", "Variables for template (format: key1=value1,key2=value2)")
+	templateAddCmd.Flags().StringVar(&templateSyntax, "syntax", templating.SyntaxBrackets, "Template syntax: brackets for [variable] placeholders, or go for Go text/template")
 	
 	templateCmd.AddCommand(templateAddCmd)
 	templateCmd.AddCommand(templateRemoveCmd)
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// reattachTerminal makes the controlling terminal stdin again once piped
+// input has been read, so prompts and $EDITOR can still talk to the user.
+func reattachTerminal() error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	return unix.Dup2(int(tty.Fd()), int(os.Stdin.Fd()))
+}
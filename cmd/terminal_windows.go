@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// reattachTerminal reports an error when stdin isn't a terminal, as there is
+// no controlling terminal to switch back to.
+func reattachTerminal() error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return errors.New("stdin is not a terminal")
+}
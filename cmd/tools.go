@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tedfulk/suggest/internal/api"
+	"github.com/tedfulk/suggest/internal/config"
+	"github.com/tedfulk/suggest/internal/tools"
+)
+
+// maxToolRounds bounds how many times in a row the model may call tools
+// before giving a final answer.
+const maxToolRounds = 10
+
+var toolsFlag bool
+
+// newToolRegistry returns the built-in local tools and the tools of the
+// configured MCP servers. The file tools only see the working directory, so
+// files such as SSH keys or the config with its API keys aren't sent to the
+// model. The returned function closes the MCP connections.
+func newToolRegistry(cfg *config.Config) (*tools.Registry, func()) {
+	root, err := os.Getwd()
+	if err != nil {
+		// A relative root still refuses every path when the working
+		// directory can't be found
+		root = "."
+	}
+	registry := tools.NewRegistry(tools.Builtin(tools.Options{
+		Root:         root,
+		TavilyAPIKey: cfg.TavilyAPIKey,
+		ConfirmShell: func(command string) bool {
+			return confirmShellCommand(cfg, command)
+		},
+	})...)
+
+	conns, closeConns := connectMCPServers(cfg)
+	for _, conn := range conns {
+		registry.Add(conn.mcpTools()...)
+	}
+	return registry, closeConns
+}
+
+func confirmShellCommand(cfg *config.Config, command string) bool {
+	fmt.Printf("\n%s %s\n", yellow("The model wants to run:"), command)
+	return confirmCommandRisk(cfg, command, true)
+}
+
+// completeWithTools offers the registry's tools to the model, runs every
+// tool it calls and feeds the results back until it gives a final answer.
+// The tool calls and results are appended to req.Messages. It returns the
+// final response and the model that gave it. params is passed on to
+// completeWithFallback.
+func completeWithTools(cfg *config.Config, provider config.Provider, req *api.ChatCompletionRequest, registry *tools.Registry, params paramsFunc) (*api.ChatCompletionResponse, string, error) {
+	req.Tools = registry.Definitions()
+
+	for round := 0; ; round++ {
+		resp, answeredProvider, answeredBy, err := completeWithFallback(cfg, provider, req, params)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(resp.Choices) == 0 || len(resp.Choices[0].Message.ToolCalls) == 0 {
+			return resp, answeredBy, nil
+		}
+		if round >= maxToolRounds {
+			return nil, "", fmt.Errorf("model was still calling tools after %d rounds", maxToolRounds)
+		}
+
+		// Keep the rest of the exchange on the model that made the calls
+		if answeredProvider != provider || answeredBy != req.Model {
+			provider = answeredProvider
+			req.Model = answeredBy
+			if params != nil {
+				params(provider, req)
+			}
+		}
+
+		message := resp.Choices[0].Message
+		message.Role = "assistant"
+		req.Messages = append(req.Messages, message)
+
+		for _, call := range message.ToolCalls {
+			fmt.Fprintf(os.Stderr, "%s %s\n", faint("→"), faint(formatToolCall(call)))
+			output, err := registry.Call(call)
+			if err != nil {
+				output = "Error: " + err.Error()
+			}
+			req.Messages = append(req.Messages, api.ChatMessage{
+				Role:       "tool",
+				Content:    output,
+				ToolCallID: call.ID,
+				Name:       call.Function.Name,
+			})
+		}
+	}
+}
+
+func formatToolCall(call api.ToolCall) string {
+	return fmt.Sprintf("%s(%s)", call.Function.Name, truncateRunes(call.Function.Arguments, 100))
+}
@@ -93,8 +93,10 @@ Example:
 			model = modelFlag
 		}
 
-		if actualModel, exists := cfg.ModelAliases[model]; exists {
-			model = actualModel
+		provider, model, err := config.ResolveModel(model, cfg)
+		if err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), err)
+			return
 		}
 
 		systemPrompt := cfg.SystemPrompt
@@ -129,55 +131,13 @@ Example:
 		})
 
 		req := &api.ChatCompletionRequest{
-			Model:       model,
-			Messages:    messages,
-			Temperature: 0.7,
+			Model:    model,
+			Messages: messages,
 		}
+		applyGenerationParams(cmd, cfg, provider, req, api.Float(0.7))
 
 		// Get response from AI
-		var resp *api.ChatCompletionResponse
-		var apiErr error
-
-		provider := config.DetermineModelProvider(model, cfg)
-		if provider == "" {
-			fmt.Printf("%s: %s. Please use a Groq, OpenAI, Gemini, or Ollama model.\n", red("Model not supported"), yellow(model))
-			return
-		}
-
-		switch provider {
-		case "groq":
-			if cfg.GroqAPIKey == "" {
-				fmt.Printf("%s. Please set it in your config file.\n", red("Groq API key not set"))
-				return
-			}
-			client := api.NewGroqClient(cfg.GroqAPIKey)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		case "openai":
-			if cfg.OpenAIAPIKey == "" {
-				fmt.Printf("%s. Please set it in your config file.\n", red("OpenAI API key not set"))
-				return
-			}
-			client := api.NewOpenAIClient(cfg.OpenAIAPIKey)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		case "gemini":
-			if cfg.GeminiAPIKey == "" {
-				fmt.Printf("%s. Please set it in your config file.\n", red("Gemini API key not set"))
-				return
-			}
-			client := api.NewGeminiClient(cfg.GeminiAPIKey)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		case "ollama":
-			client := api.NewOllamaClient(cfg.OllamaHost)
-			resp, apiErr = client.CreateChatCompletion(req)
-		
-		default:
-			fmt.Printf("%s: %s. Please use a Groq, OpenAI, Gemini, or Ollama model.\n", red("Model not supported"), yellow(model))
-			return
-		}
-
+		resp, apiErr := getResponse(provider, cfg, req)
 		if apiErr != nil {
 			fmt.Printf("%s: %v\n", red("Error getting AI response"), apiErr)
 			return
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// APIError is returned when a provider answers with a non-success status.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsRetryable reports whether a failed request may succeed with another
+// model or provider: rate limits, server errors, timeouts and network
+// failures. Client errors such as a bad key or request are not retryable.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return apiErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// Forward posts an already encoded request to the OpenAI chat completions
+// endpoint and returns the raw response, so it can be relayed unchanged,
+// event streams included. The caller closes the response body.
+func (c *OpenAIClient) Forward(body []byte) (*http.Response, error) {
+	return forward(c.client, OpenAIAPIEndpoint, c.APIKey, body)
+}
+
+// Forward is the Groq counterpart of OpenAIClient.Forward.
+func (c *GroqClient) Forward(body []byte) (*http.Response, error) {
+	return forward(c.client, GroqAPIEndpoint, c.APIKey, body)
+}
+
+func forward(client *http.Client, endpoint, apiKey string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	return resp, nil
+}
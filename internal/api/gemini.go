@@ -18,7 +18,21 @@ type GeminiClient struct {
 }
 
 type GeminiRequest struct {
-	Contents []GeminiContent `json:"contents"`
+	Contents         []GeminiContent         `json:"contents"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	Tools            []GeminiTool            `json:"tools,omitempty"`
+}
+
+type GeminiTool struct {
+	FunctionDeclarations []ToolFunction `json:"functionDeclarations"`
+}
+
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+	Seed            *int     `json:"seed,omitempty"`
 }
 
 type GeminiContent struct {
@@ -27,7 +41,19 @@ type GeminiContent struct {
 }
 
 type GeminiPart struct {
-	Text string `json:"text"`
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type GeminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type GeminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
 }
 
 func NewGeminiClient(apiKey string) *GeminiClient {
@@ -59,9 +85,49 @@ func (c *GeminiClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 			role = "user" // default to user if unknown role
 		}
 
+		parts := []GeminiPart{{Text: msg.Content}}
+		switch {
+		case msg.Role == "tool":
+			// Tool results go back as a function response, matched to the
+			// call by function name
+			parts = []GeminiPart{{FunctionResponse: &GeminiFunctionResponse{
+				Name:     msg.Name,
+				Response: map[string]interface{}{"content": msg.Content},
+			}}}
+		case len(msg.ToolCalls) > 0:
+			parts = nil
+			if msg.Content != "" {
+				parts = append(parts, GeminiPart{Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				args := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				parts = append(parts, GeminiPart{FunctionCall: &GeminiFunctionCall{
+					Name: call.Function.Name,
+					Args: args,
+				}})
+			}
+		}
+
 		geminiReq.Contents[i] = GeminiContent{
 			Role:  role,
-			Parts: []GeminiPart{{Text: msg.Content}},
+			Parts: parts,
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		geminiReq.Tools = []GeminiTool{{FunctionDeclarations: geminiDeclarations(req.Tools)}}
+	}
+
+	if req.Temperature != nil || req.TopP != nil || req.MaxTokens > 0 || len(req.Stop) > 0 || req.Seed != nil {
+		geminiReq.GenerationConfig = &GeminiGenerationConfig{
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			MaxOutputTokens: req.MaxTokens,
+			StopSequences:   req.Stop,
+			Seed:            req.Seed,
 		}
 	}
 
@@ -94,11 +160,14 @@ func (c *GeminiClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 	var geminiResp struct {
 		Candidates []struct {
 			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
+				Parts []GeminiPart `json:"parts"`
 			} `json:"content"`
 		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			TotalTokenCount      int `json:"totalTokenCount"`
+		} `json:"usageMetadata"`
 		Error struct {
 			Code    int    `json:"code"`
 			Message string `json:"message"`
@@ -107,12 +176,19 @@ func (c *GeminiClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 	}
 
 	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		}
 		return nil, fmt.Errorf("error parsing response: %w, body: %s", err, string(body))
 	}
 
 	// Check for API error
 	if geminiResp.Error.Message != "" {
-		return nil, fmt.Errorf("API error: %s", geminiResp.Error.Message)
+		statusCode := resp.StatusCode
+		if geminiResp.Error.Code != 0 {
+			statusCode = geminiResp.Error.Code
+		}
+		return nil, &APIError{StatusCode: statusCode, Body: geminiResp.Error.Message}
 	}
 
 	result := &ChatCompletionResponse{
@@ -121,21 +197,169 @@ func (c *GeminiClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 			Message ChatMessage `json:"message"`
 		}{},
 	}
+	result.Model = req.Model
+	result.Usage.PromptTokens = geminiResp.UsageMetadata.PromptTokenCount
+	result.Usage.CompletionTokens = geminiResp.UsageMetadata.CandidatesTokenCount
+	result.Usage.TotalTokens = geminiResp.UsageMetadata.TotalTokenCount
 
 	for _, candidate := range geminiResp.Candidates {
 		if len(candidate.Content.Parts) > 0 {
+			message := ChatMessage{Role: "assistant"}
+			for _, part := range candidate.Content.Parts {
+				message.Content += part.Text
+				if part.FunctionCall != nil {
+					args := string(part.FunctionCall.Args)
+					if args == "" {
+						args = "{}"
+					}
+					// Gemini doesn't assign call IDs, so number the calls
+					message.ToolCalls = append(message.ToolCalls, ToolCall{
+						ID:   fmt.Sprintf("call_%d", len(message.ToolCalls)),
+						Type: "function",
+						Function: ToolCallFunction{
+							Name:      part.FunctionCall.Name,
+							Arguments: args,
+						},
+					})
+				}
+			}
+
 			result.Choices = append(result.Choices, struct {
 				Index   int         `json:"index"`
 				Message ChatMessage `json:"message"`
 			}{
-				Index: len(result.Choices),
-				Message: ChatMessage{
-					Role:    "assistant",
-					Content: candidate.Content.Parts[0].Text,
-				},
+				Index:   len(result.Choices),
+				Message: message,
 			})
 		}
 	}
 
 	return result, nil
 }
+
+// geminiDeclarations converts tools to Gemini function declarations. Their
+// parameters are translated to the OpenAPI schema subset Gemini accepts.
+func geminiDeclarations(tools []Tool) []ToolFunction {
+	declarations := make([]ToolFunction, len(tools))
+	for i, tool := range tools {
+		declarations[i] = tool.Function
+		params := geminiSchema(tool.Function.Parameters)
+		// Gemini rejects objects without properties, so tools that take no
+		// arguments declare no parameters
+		if props, _ := params["properties"].(map[string]interface{}); len(props) == 0 {
+			params = nil
+		}
+		declarations[i].Parameters = params
+	}
+	return declarations
+}
+
+// geminiSchemaKeys are the schema keys Gemini accepts.
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true, "nullable": true,
+	"enum": true, "items": true, "properties": true, "required": true, "anyOf": true,
+	"minItems": true, "maxItems": true, "minProperties": true, "maxProperties": true,
+	"minLength": true, "maxLength": true, "pattern": true, "minimum": true, "maximum": true,
+	"default": true, "example": true, "propertyOrdering": true,
+}
+
+// geminiFormats are the formats Gemini accepts.
+var geminiFormats = map[string]bool{
+	"enum": true, "date-time": true, "float": true, "double": true, "int32": true, "int64": true,
+}
+
+// geminiSchema translates a JSON schema, as MCP servers send it, to what
+// Gemini accepts. Keys such as $schema and additionalProperties are
+// dropped, const becomes a one-value enum, oneOf becomes anyOf and a type
+// list such as ["string", "null"] becomes a nullable type.
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		return nil
+	}
+	out := make(map[string]interface{})
+	for key, value := range schema {
+		switch key {
+		case "type":
+			if types, ok := value.([]interface{}); ok {
+				for _, t := range types {
+					if t == "null" {
+						out["nullable"] = true
+					} else if _, set := out["type"]; !set {
+						out["type"] = t
+					}
+				}
+				continue
+			}
+			out["type"] = value
+		case "const":
+			if _, ok := value.(string); ok {
+				out["enum"] = []interface{}{value}
+			}
+		case "enum":
+			// Gemini only takes string enums
+			if values, ok := value.([]interface{}); ok && allStrings(values) {
+				out["enum"] = values
+			}
+		case "format":
+			if format, ok := value.(string); ok && geminiFormats[format] {
+				out["format"] = format
+			}
+		case "properties":
+			if props, ok := value.(map[string]interface{}); ok {
+				converted := make(map[string]interface{}, len(props))
+				for name, prop := range props {
+					if propSchema, ok := prop.(map[string]interface{}); ok {
+						converted[name] = geminiSchema(propSchema)
+					}
+				}
+				out["properties"] = converted
+			}
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				out["items"] = geminiSchema(items)
+			}
+		case "anyOf", "oneOf":
+			if options, ok := value.([]interface{}); ok {
+				var converted []interface{}
+				for _, option := range options {
+					if optionSchema, ok := option.(map[string]interface{}); ok {
+						converted = append(converted, geminiSchema(optionSchema))
+					}
+				}
+				out["anyOf"] = converted
+			}
+		default:
+			if geminiSchemaKeys[key] {
+				out[key] = value
+			}
+		}
+	}
+	if _, ok := out["enum"]; ok && out["type"] == nil {
+		out["type"] = "string"
+	}
+	// Required names must be among the properties
+	if required, ok := out["required"].([]interface{}); ok {
+		props, _ := out["properties"].(map[string]interface{})
+		var kept []interface{}
+		for _, name := range required {
+			if s, ok := name.(string); ok && props[s] != nil {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) > 0 {
+			out["required"] = kept
+		} else {
+			delete(out, "required")
+		}
+	}
+	return out
+}
+
+func allStrings(values []interface{}) bool {
+	for _, v := range values {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
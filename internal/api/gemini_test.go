@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGeminiDeclarations(t *testing.T) {
+	// A schema as MCP servers typically send it, generated from zod or
+	// pydantic
+	var schema map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"url": {"type": "string", "format": "uri", "description": "Page to fetch"},
+			"limit": {"type": ["integer", "null"], "minimum": 1, "exclusiveMinimum": 0},
+			"mode": {"const": "fast"},
+			"level": {"enum": [1, 2, 3], "type": "integer"},
+			"since": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": "string", "minLength": 1}},
+			"headers": {
+				"type": "object",
+				"properties": {"name": {"type": "string"}},
+				"required": ["name"],
+				"additionalProperties": false
+			},
+			"target": {"oneOf": [{"type": "string"}, {"type": "number"}]}
+		},
+		"required": ["url", "missing"],
+		"additionalProperties": false,
+		"$defs": {"unused": {"type": "string"}}
+	}`), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tools := []Tool{
+		{Type: "function", Function: ToolFunction{Name: "fetch", Description: "Fetch a page", Parameters: schema}},
+		{Type: "function", Function: ToolFunction{Name: "now", Parameters: map[string]interface{}{
+			"type": "object", "properties": map[string]interface{}{}, "additionalProperties": false,
+		}}},
+	}
+	got := geminiDeclarations(tools)
+
+	var want map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string", "description": "Page to fetch"},
+			"limit": {"type": "integer", "nullable": true, "minimum": 1},
+			"mode": {"type": "string", "enum": ["fast"]},
+			"level": {"type": "integer"},
+			"since": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": "string", "minLength": 1}},
+			"headers": {
+				"type": "object",
+				"properties": {"name": {"type": "string"}},
+				"required": ["name"]
+			},
+			"target": {"anyOf": [{"type": "string"}, {"type": "number"}]}
+		},
+		"required": ["url"]
+	}`), &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got[0].Name != "fetch" || got[0].Description != "Fetch a page" {
+		t.Errorf("declaration = %+v", got[0])
+	}
+	if !reflect.DeepEqual(roundTrip(t, got[0].Parameters), want) {
+		data, _ := json.MarshalIndent(got[0].Parameters, "", "  ")
+		t.Errorf("parameters =\n%s", data)
+	}
+	if got[1].Parameters != nil {
+		t.Errorf("parameters of a tool without arguments = %v, want none", got[1].Parameters)
+	}
+	if _, ok := schema["$schema"]; !ok {
+		t.Error("the tool's own schema was modified")
+	}
+}
+
+// roundTrip normalizes v to what encoding/json decodes.
+func roundTrip(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -57,7 +57,7 @@ func (c *GroqClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatComp
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var result ChatCompletionResponse
@@ -4,21 +4,72 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const DefaultOllamaEndpoint = "http://localhost:11434"
 
 type OllamaClient struct {
-	Host   string
-	client *http.Client
+	Host string
+	// Options are default model options such as num_ctx, overridden by the
+	// generation parameters of each request
+	Options map[string]interface{}
+	// KeepAlive controls how long the model stays loaded after a request,
+	// e.g. "10m", or "-1" to keep it loaded
+	KeepAlive string
+	client    *http.Client
 }
 
 type OllamaRequest struct {
-	Model    string        `json:"model"`
-	Messages []ChatMessage `json:"messages"`
+	Model     string                 `json:"model"`
+	Messages  []OllamaMessage        `json:"messages"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive interface{}            `json:"keep_alive,omitempty"`
+	Tools     []Tool                 `json:"tools,omitempty"`
+}
+
+// OllamaMessage differs from ChatMessage in that tool call arguments are a
+// JSON object rather than a string, and tool results carry the tool name.
+type OllamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type OllamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// PullProgress is a status update streamed while a model is pulled.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RunningModel is a model currently loaded into memory.
+type RunningModel struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model"`
+	Size      int64     `json:"size"`
+	SizeVRAM  int64     `json:"size_vram"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Details   struct {
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
 }
 
 func NewOllamaClient(host string) *OllamaClient {
@@ -32,9 +83,65 @@ func NewOllamaClient(host string) *OllamaClient {
 }
 
 func (c *OllamaClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	return c.CreateChatCompletionStream(req, nil)
+}
+
+// CreateChatCompletionStream is like CreateChatCompletion, but calls
+// onContent with every piece of the answer as it is generated.
+func (c *OllamaClient) CreateChatCompletionStream(req *ChatCompletionRequest, onContent func(string)) (*ChatCompletionResponse, error) {
 	ollamaReq := OllamaRequest{
-		Model:    req.Model,
-		Messages: req.Messages,
+		Model: req.Model,
+		Tools: req.Tools,
+	}
+	for _, msg := range req.Messages {
+		ollamaMsg := OllamaMessage{
+			Role:     msg.Role,
+			Content:  msg.Content,
+			ToolName: msg.Name,
+		}
+		for _, call := range msg.ToolCalls {
+			var ollamaCall OllamaToolCall
+			ollamaCall.Function.Name = call.Function.Name
+			ollamaCall.Function.Arguments = json.RawMessage(call.Function.Arguments)
+			if !json.Valid(ollamaCall.Function.Arguments) {
+				ollamaCall.Function.Arguments = json.RawMessage("{}")
+			}
+			ollamaMsg.ToolCalls = append(ollamaMsg.ToolCalls, ollamaCall)
+		}
+		ollamaReq.Messages = append(ollamaReq.Messages, ollamaMsg)
+	}
+	if c.KeepAlive != "" {
+		// A plain number is a duration in seconds, anything else a
+		// duration string such as "10m"
+		if seconds, err := strconv.Atoi(c.KeepAlive); err == nil {
+			ollamaReq.KeepAlive = seconds
+		} else {
+			ollamaReq.KeepAlive = c.KeepAlive
+		}
+	}
+
+	// Ollama takes sampling parameters as model options
+	options := make(map[string]interface{})
+	for k, v := range c.Options {
+		options[k] = v
+	}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		options["top_p"] = *req.TopP
+	}
+	if req.Seed != nil {
+		options["seed"] = *req.Seed
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if len(req.Stop) > 0 {
+		options["stop"] = req.Stop
+	}
+	if len(options) > 0 {
+		ollamaReq.Options = options
 	}
 
 	jsonData, err := json.Marshal(ollamaReq)
@@ -56,20 +163,48 @@ func (c *OllamaClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
 	// Accumulate the full response
 	var fullMessage strings.Builder
+	var toolCalls []ToolCall
+	var promptTokens, completionTokens int
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
 		var streamResp struct {
 			Message struct {
-				Content string `json:"content"`
+				Content   string           `json:"content"`
+				ToolCalls []OllamaToolCall `json:"tool_calls"`
 			} `json:"message"`
-			Done bool `json:"done"`
+			Done            bool `json:"done"`
+			PromptEvalCount int  `json:"prompt_eval_count"`
+			EvalCount       int  `json:"eval_count"`
 		}
 		if err := json.Unmarshal(scanner.Bytes(), &streamResp); err != nil {
 			continue // Skip malformed lines
 		}
 		fullMessage.WriteString(streamResp.Message.Content)
+		if onContent != nil && streamResp.Message.Content != "" {
+			onContent(streamResp.Message.Content)
+		}
+		for _, call := range streamResp.Message.ToolCalls {
+			// Ollama doesn't assign call IDs, so number the calls
+			toolCalls = append(toolCalls, ToolCall{
+				ID:   fmt.Sprintf("call_%d", len(toolCalls)),
+				Type: "function",
+				Function: ToolCallFunction{
+					Name:      call.Function.Name,
+					Arguments: string(call.Function.Arguments),
+				},
+			})
+		}
+		if streamResp.Done {
+			promptTokens = streamResp.PromptEvalCount
+			completionTokens = streamResp.EvalCount
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -77,6 +212,7 @@ func (c *OllamaClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 	}
 
 	result := &ChatCompletionResponse{
+		Model: req.Model,
 		Choices: []struct {
 			Index   int         `json:"index"`
 			Message ChatMessage `json:"message"`
@@ -84,12 +220,115 @@ func (c *OllamaClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 			{
 				Index: 0,
 				Message: ChatMessage{
-					Role:    "assistant",
-					Content: fullMessage.String(),
+					Role:      "assistant",
+					Content:   fullMessage.String(),
+					ToolCalls: toolCalls,
 				},
 			},
 		},
 	}
+	result.Usage.PromptTokens = promptTokens
+	result.Usage.CompletionTokens = completionTokens
+	result.Usage.TotalTokens = promptTokens + completionTokens
 
 	return result, nil
-} 
\ No newline at end of file
+}
+
+// IsModelNotFound reports whether Ollama rejected a request because the
+// model has not been pulled.
+func IsModelNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound &&
+		strings.Contains(apiErr.Body, "not found")
+}
+
+// Pull downloads a model, calling progress for every status update.
+func (c *OllamaClient) Pull(model string, progress func(PullProgress)) error {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"model":  model,
+		"stream": true,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/pull", c.Host)
+	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var update PullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			continue // Skip malformed lines
+		}
+		if update.Error != "" {
+			return fmt.Errorf("%s", update.Error)
+		}
+		if progress != nil {
+			progress(update)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %w", err)
+	}
+	return nil
+}
+
+// Running lists the models currently loaded into memory.
+func (c *OllamaClient) Running() ([]RunningModel, error) {
+	url := fmt.Sprintf("%s/api/ps", c.Host)
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var psResp struct {
+		Models []RunningModel `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&psResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	return psResp.Models, nil
+}
+
+// Delete removes a model and its data from the Ollama host.
+func (c *OllamaClient) Delete(model string) error {
+	jsonData, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/delete", c.Host)
+	httpReq, err := http.NewRequest("DELETE", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 const OpenAIAPIEndpoint = "https://api.openai.com/v1/chat/completions"
@@ -23,8 +24,50 @@ func NewOpenAIClient(apiKey string) *OpenAIClient {
 	}
 }
 
+// openAIRequest is a request as the OpenAI API takes it. Its fields shadow
+// those of the embedded request with the same JSON names.
+type openAIRequest struct {
+	*ChatCompletionRequest
+	// MaxTokens is always zero, so max_tokens is left out in favor of
+	// max_completion_tokens, which reasoning models require
+	MaxTokens           int      `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int      `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64 `json:"temperature,omitempty"`
+	TopP                *float64 `json:"top_p,omitempty"`
+	Stop                []string `json:"stop,omitempty"`
+}
+
+// newOpenAIRequest maps req to the OpenAI API. Reasoning models reject
+// the sampling parameters, so they are left out for them.
+func newOpenAIRequest(req *ChatCompletionRequest) *openAIRequest {
+	wire := &openAIRequest{
+		ChatCompletionRequest: req,
+		MaxCompletionTokens:   req.MaxTokens,
+	}
+	if !isOpenAIReasoningModel(req.Model) {
+		wire.Temperature = req.Temperature
+		wire.TopP = req.TopP
+		wire.Stop = req.Stop
+	}
+	return wire
+}
+
+// isOpenAIReasoningModel reports whether model is one of OpenAI's reasoning
+// models, such as o3-mini or gpt-5, which only take the default sampling
+// parameters.
+func isOpenAIReasoningModel(model string) bool {
+	if strings.HasPrefix(model, "gpt-5") {
+		return !strings.Contains(model, "-chat")
+	}
+	if len(model) < 2 || model[0] != 'o' {
+		return false
+	}
+	rest := strings.TrimLeft(model[1:], "0123456789")
+	return len(rest) < len(model)-1 && (rest == "" || rest[0] == '-')
+}
+
 func (c *OpenAIClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	jsonData, err := json.Marshal(req)
+	jsonData, err := json.Marshal(newOpenAIRequest(req))
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
@@ -50,7 +93,7 @@ func (c *OpenAIClient) CreateChatCompletion(req *ChatCompletionRequest) (*ChatCo
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var result ChatCompletionResponse
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewOpenAIRequest(t *testing.T) {
+	seed := 1
+	tests := []struct {
+		model string
+		want  map[string]bool
+	}{
+		{model: "gpt-4o", want: map[string]bool{"temperature": true, "top_p": true, "stop": true}},
+		{model: "gpt-5-chat-latest", want: map[string]bool{"temperature": true, "top_p": true, "stop": true}},
+		{model: "o1", want: map[string]bool{}},
+		{model: "o3-mini", want: map[string]bool{}},
+		{model: "o4-mini-2025-04-16", want: map[string]bool{}},
+		{model: "gpt-5-mini", want: map[string]bool{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			req := &ChatCompletionRequest{
+				Model:       tt.model,
+				Messages:    []ChatMessage{{Role: "user", Content: "Hi"}},
+				Temperature: Float(0.7),
+				TopP:        Float(0.9),
+				Seed:        &seed,
+				Stop:        []string{"END"},
+				MaxTokens:   100,
+			}
+			data, err := json.Marshal(newOpenAIRequest(req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := got["max_tokens"]; ok {
+				t.Error("max_tokens sent")
+			}
+			if got["max_completion_tokens"] != float64(100) {
+				t.Errorf("max_completion_tokens = %v, want 100", got["max_completion_tokens"])
+			}
+			if got["model"] != tt.model || got["seed"] != float64(1) || got["messages"] == nil {
+				t.Errorf("request = %s", data)
+			}
+			for _, field := range []string{"temperature", "top_p", "stop"} {
+				if _, ok := got[field]; ok != tt.want[field] {
+					t.Errorf("%s sent = %v, want %v", field, ok, tt.want[field])
+				}
+			}
+		})
+	}
+}
+
+func TestIsOpenAIReasoningModel(t *testing.T) {
+	for model, want := range map[string]bool{
+		"o1": true, "o1-mini": true, "o3": true, "o4-mini": true, "gpt-5": true, "gpt-5-nano": true,
+		"gpt-4o": false, "gpt-5-chat-latest": false, "omni-moderation-latest": false, "o": false,
+	} {
+		if got := isOpenAIReasoningModel(model); got != want {
+			t.Errorf("isOpenAIReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
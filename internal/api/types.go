@@ -3,14 +3,57 @@ package api
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// ToolCalls are the tools an assistant message asks to have called
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID and Name identify the call a "tool" message answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
 }
 
+// Tool describes a function the model may call, in the OpenAI format.
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is a JSON schema object describing the arguments
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ToolCall is a request from the model to call a tool. Arguments holds the
+// arguments as a JSON object encoded in a string.
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatCompletionRequest follows the OpenAI wire format. Optional sampling
+// parameters are pointers so that zero values such as a temperature of 0
+// are still sent.
 type ChatCompletionRequest struct {
 	Model       string        `json:"model"`
 	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature,omitempty"`
-	MaxTokens   int          `json:"max_tokens,omitempty"`
-	Stream      bool         `json:"stream,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	Seed        *int          `json:"seed,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+	Tools       []Tool        `json:"tools,omitempty"`
+}
+
+// Float returns a pointer to v, for optional request parameters.
+func Float(v float64) *float64 {
+	return &v
 }
 
 type ChatCompletionResponse struct {
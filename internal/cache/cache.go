@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+)
+
+// Cache stores chat completion responses on disk, one JSON file per request.
+type Cache struct {
+	Dir      string
+	TTL      time.Duration
+	MaxBytes int64
+}
+
+// Entry is a cached response together with what produced it.
+type Entry struct {
+	Key       string                      `json:"key"`
+	Provider  string                      `json:"provider"`
+	Model     string                      `json:"model"`
+	CreatedAt time.Time                   `json:"created_at"`
+	Response  *api.ChatCompletionResponse `json:"response"`
+}
+
+// Stats summarises the contents of the cache.
+type Stats struct {
+	Entries int
+	Expired int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+func New(dir string, ttl time.Duration, maxBytes int64) *Cache {
+	return &Cache{
+		Dir:      dir,
+		TTL:      ttl,
+		MaxBytes: maxBytes,
+	}
+}
+
+// Key hashes the provider and the full request, so the model, messages and
+// every generation parameter such as temperature and max tokens take part.
+func Key(provider string, req *api.ChatCompletionRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(provider))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// Get returns the cached response for key if it exists and has not expired.
+func (c *Cache) Get(key string) (*api.ChatCompletionResponse, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Response == nil {
+		return nil, false
+	}
+	if c.TTL > 0 && time.Since(entry.CreatedAt) > c.TTL {
+		os.Remove(c.path(key))
+		return nil, false
+	}
+	return entry.Response, true
+}
+
+// Put stores a response and prunes the cache back under its size limit.
+func (c *Cache) Put(key, provider string, resp *api.ChatCompletionResponse) error {
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Entry{
+		Key:       key,
+		Provider:  provider,
+		Model:     resp.Model,
+		CreatedAt: time.Now(),
+		Response:  resp,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file of our own first, so concurrent readers
+	// never see a partially written entry and concurrent writers of the
+	// same key don't interleave
+	tmp, err := os.CreateTemp(c.Dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return c.Prune()
+}
+
+// staleTempAge is how old a temporary file must be before Prune treats it
+// as left behind by a crashed writer rather than one still writing.
+const staleTempAge = time.Minute
+
+// tempFiles returns the temporary files of unfinished writes.
+func (c *Cache) tempFiles() []fileInfo {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []fileInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".tmp") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{
+			path:    filepath.Join(c.Dir, e.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files
+}
+
+type fileInfo struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache) files() ([]fileInfo, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []fileInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{
+			path:    filepath.Join(c.Dir, e.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// Prune removes expired entries and temporary files left by crashed
+// writers, then the oldest entries until the cache fits in MaxBytes.
+func (c *Cache) Prune() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range c.tempFiles() {
+		if time.Since(f.modTime) > staleTempAge {
+			os.Remove(f.path)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	var total int64
+	var kept []fileInfo
+	for _, f := range files {
+		if c.TTL > 0 && time.Since(f.modTime) > c.TTL {
+			os.Remove(f.path)
+			continue
+		}
+		total += f.size
+		kept = append(kept, f)
+	}
+
+	for _, f := range kept {
+		if c.MaxBytes <= 0 || total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+	return nil
+}
+
+// Stats reports the number and size of cached entries.
+func (c *Cache) Stats() (Stats, error) {
+	var stats Stats
+
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+
+	for _, f := range files {
+		stats.Entries++
+		stats.Bytes += f.size
+		if c.TTL > 0 && time.Since(f.modTime) > c.TTL {
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || f.modTime.Before(stats.Oldest) {
+			stats.Oldest = f.modTime
+		}
+		if f.modTime.After(stats.Newest) {
+			stats.Newest = f.modTime
+		}
+	}
+	return stats, nil
+}
+
+// Clear removes every cached entry, and any temporary files, and returns
+// how many entries were removed.
+func (c *Cache) Clear() (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range c.tempFiles() {
+		os.Remove(f.path)
+	}
+
+	removed := 0
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
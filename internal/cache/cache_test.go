@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+)
+
+func TestConcurrentPut(t *testing.T) {
+	c := New(t.TempDir(), time.Hour, 0)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.Put("key", "ollama", &api.ChatCompletionResponse{Model: "m"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	if resp, ok := c.Get("key"); !ok || resp.Model != "m" {
+		t.Fatalf("Get = %v, %v", resp, ok)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(c.Dir, "*.tmp")); len(tmps) > 0 {
+		t.Errorf("temporary files left: %v", tmps)
+	}
+}
+
+func TestPruneAndClearTempFiles(t *testing.T) {
+	c := New(t.TempDir(), time.Hour, 0)
+	if err := c.Put("key", "ollama", &api.ChatCompletionResponse{Model: "m"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := filepath.Join(c.Dir, "stale-1.tmp")
+	fresh := filepath.Join(c.Dir, "fresh-1.tmp")
+	for _, path := range []string{stale, fresh} {
+		if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * staleTempAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Prune kept a stale temporary file")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Error("Prune removed a temporary file still being written")
+	}
+
+	removed, err := c.Clear()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("Clear removed %d entries, want 1", removed)
+	}
+	if entries, _ := os.ReadDir(c.Dir); len(entries) != 0 {
+		t.Errorf("files left after Clear: %v", entries)
+	}
+}
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultModelCacheTTL is how long a provider's model list is trusted before
+// it is fetched again.
+const DefaultModelCacheTTL = time.Hour
+
+// ModelInfo describes a model offered by a provider. Prices are in USD per
+// million tokens and are zero when unknown.
+type ModelInfo struct {
+	ID            string   `json:"id"`
+	Provider      Provider `json:"provider"`
+	ContextLength int      `json:"context_length,omitempty"`
+	Vision        bool     `json:"vision,omitempty"`
+	Tools         bool     `json:"tools,omitempty"`
+	InputPrice    float64  `json:"input_price,omitempty"`
+	OutputPrice   float64  `json:"output_price,omitempty"`
+}
+
+// ProviderCatalog is the cached model list of a single provider.
+type ProviderCatalog struct {
+	Models    []ModelInfo `json:"models"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Catalog is the on-disk cache of models across all providers.
+type Catalog struct {
+	Providers map[Provider]*ProviderCatalog `json:"providers"`
+}
+
+// knownModels holds metadata the provider APIs do not report. Entries match
+// model IDs by prefix, so dated snapshots inherit their family's metadata.
+var knownModels = []ModelInfo{
+	{ID: "gpt-4o-mini", Provider: ProviderOpenAI, ContextLength: 128000, Vision: true, Tools: true, InputPrice: 0.15, OutputPrice: 0.60},
+	{ID: "gpt-4o", Provider: ProviderOpenAI, ContextLength: 128000, Vision: true, Tools: true, InputPrice: 2.50, OutputPrice: 10.00},
+	{ID: "gpt-4.1-nano", Provider: ProviderOpenAI, ContextLength: 1047576, Vision: true, Tools: true, InputPrice: 0.10, OutputPrice: 0.40},
+	{ID: "gpt-4.1-mini", Provider: ProviderOpenAI, ContextLength: 1047576, Vision: true, Tools: true, InputPrice: 0.40, OutputPrice: 1.60},
+	{ID: "gpt-4.1", Provider: ProviderOpenAI, ContextLength: 1047576, Vision: true, Tools: true, InputPrice: 2.00, OutputPrice: 8.00},
+	{ID: "gpt-4-turbo", Provider: ProviderOpenAI, ContextLength: 128000, Vision: true, Tools: true, InputPrice: 10.00, OutputPrice: 30.00},
+	{ID: "gpt-3.5-turbo", Provider: ProviderOpenAI, ContextLength: 16385, Tools: true, InputPrice: 0.50, OutputPrice: 1.50},
+	{ID: "o1", Provider: ProviderOpenAI, ContextLength: 200000, Vision: true, Tools: true, InputPrice: 15.00, OutputPrice: 60.00},
+	{ID: "o3-mini", Provider: ProviderOpenAI, ContextLength: 200000, Tools: true, InputPrice: 1.10, OutputPrice: 4.40},
+	{ID: "o4-mini", Provider: ProviderOpenAI, ContextLength: 200000, Vision: true, Tools: true, InputPrice: 1.10, OutputPrice: 4.40},
+	{ID: "llama-3.3-70b-versatile", Provider: ProviderGroq, Tools: true, InputPrice: 0.59, OutputPrice: 0.79},
+	{ID: "llama-3.1-8b-instant", Provider: ProviderGroq, Tools: true, InputPrice: 0.05, OutputPrice: 0.08},
+	{ID: "meta-llama/llama-4-scout", Provider: ProviderGroq, Vision: true, Tools: true, InputPrice: 0.11, OutputPrice: 0.34},
+	{ID: "meta-llama/llama-4-maverick", Provider: ProviderGroq, Vision: true, Tools: true, InputPrice: 0.20, OutputPrice: 0.60},
+	{ID: "qwen/qwen3-32b", Provider: ProviderGroq, Tools: true, InputPrice: 0.29, OutputPrice: 0.59},
+	{ID: "moonshotai/kimi-k2", Provider: ProviderGroq, Tools: true, InputPrice: 1.00, OutputPrice: 3.00},
+	{ID: "gemini-2.5-pro", Provider: ProviderGemini, Vision: true, Tools: true, InputPrice: 1.25, OutputPrice: 10.00},
+	{ID: "gemini-2.5-flash", Provider: ProviderGemini, Vision: true, Tools: true, InputPrice: 0.30, OutputPrice: 2.50},
+	{ID: "gemini-2.0-flash", Provider: ProviderGemini, Vision: true, Tools: true, InputPrice: 0.10, OutputPrice: 0.40},
+	{ID: "gemini-1.5-pro", Provider: ProviderGemini, Vision: true, Tools: true, InputPrice: 1.25, OutputPrice: 5.00},
+	{ID: "gemini-1.5-flash", Provider: ProviderGemini, Vision: true, Tools: true, InputPrice: 0.075, OutputPrice: 0.30},
+}
+
+func init() {
+	// Longest prefixes first so "gpt-4o-mini" wins over "gpt-4o"
+	sort.SliceStable(knownModels, func(i, j int) bool {
+		return len(knownModels[i].ID) > len(knownModels[j].ID)
+	})
+}
+
+// enrichModel fills in metadata the provider did not report from the
+// built-in table of known models.
+func enrichModel(info ModelInfo) ModelInfo {
+	for _, known := range knownModels {
+		if known.Provider != info.Provider || !strings.HasPrefix(info.ID, known.ID) {
+			continue
+		}
+		if info.ContextLength == 0 {
+			info.ContextLength = known.ContextLength
+		}
+		info.Vision = info.Vision || known.Vision
+		info.Tools = info.Tools || known.Tools
+		if info.InputPrice == 0 && info.OutputPrice == 0 {
+			info.InputPrice = known.InputPrice
+			info.OutputPrice = known.OutputPrice
+		}
+		break
+	}
+	return info
+}
+
+// CatalogPath returns the location of the cached model catalog.
+func CatalogPath() (string, error) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "models.json"), nil
+}
+
+// ModelCacheTTL returns how long cached model lists are considered fresh.
+func ModelCacheTTL(cfg *Config) time.Duration {
+	if cfg.ModelCacheTTL != "" {
+		if ttl, err := time.ParseDuration(cfg.ModelCacheTTL); err == nil {
+			return ttl
+		}
+	}
+	return DefaultModelCacheTTL
+}
+
+// ConfiguredProviders returns the providers suggest can query with the
+// current config. Ollama needs no key, so it is always included.
+func ConfiguredProviders(cfg *Config) []Provider {
+	var providers []Provider
+	if cfg.OpenAIAPIKey != "" {
+		providers = append(providers, ProviderOpenAI)
+	}
+	if cfg.GroqAPIKey != "" {
+		providers = append(providers, ProviderGroq)
+	}
+	if cfg.GeminiAPIKey != "" {
+		providers = append(providers, ProviderGemini)
+	}
+	providers = append(providers, ProviderOllama)
+	return providers
+}
+
+// LoadCatalog reads the cached model catalog. A missing or unreadable cache
+// yields an empty catalog.
+func LoadCatalog() (*Catalog, error) {
+	catalog := &Catalog{Providers: make(map[Provider]*ProviderCatalog)}
+
+	catalogPath, err := CatalogPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(catalogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return catalog, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, catalog); err != nil || catalog.Providers == nil {
+		// A corrupt cache is simply rebuilt on the next refresh
+		catalog.Providers = make(map[Provider]*ProviderCatalog)
+	}
+	return catalog, nil
+}
+
+// SaveCatalog writes the model catalog to disk.
+func SaveCatalog(catalog *Catalog) error {
+	catalogPath, err := CatalogPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(catalogPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers and concurrent writers
+	// never see a partially written catalog
+	tmp, err := os.CreateTemp(filepath.Dir(catalogPath), "models-*.json.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), catalogPath); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// RefreshCatalog fetches the model lists of the given providers concurrently
+// and stores them in the on-disk catalog. When a provider cannot be reached
+// its previously cached models are kept and the error is recorded.
+func RefreshCatalog(cfg *Config, providers ...Provider) (*Catalog, error) {
+	catalog, err := LoadCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		provider Provider
+		models   []ModelInfo
+		err      error
+	}
+
+	results := make(chan result, len(providers))
+	var wg sync.WaitGroup
+	for _, provider := range providers {
+		wg.Add(1)
+		go func(provider Provider) {
+			defer wg.Done()
+			models, err := FetchModelInfo(provider, cfg)
+			results <- result{provider: provider, models: models, err: err}
+		}(provider)
+	}
+	wg.Wait()
+	close(results)
+
+	for r := range results {
+		entry := catalog.Providers[r.provider]
+		if entry == nil {
+			entry = &ProviderCatalog{}
+			catalog.Providers[r.provider] = entry
+		}
+
+		if r.err != nil {
+			entry.Error = r.err.Error()
+			continue
+		}
+
+		models := make([]ModelInfo, 0, len(r.models))
+		for _, m := range r.models {
+			models = append(models, enrichModel(m))
+		}
+		sort.Slice(models, func(i, j int) bool {
+			return models[i].ID < models[j].ID
+		})
+
+		entry.Models = models
+		entry.FetchedAt = time.Now()
+		entry.Error = ""
+	}
+
+	if err := SaveCatalog(catalog); err != nil {
+		return catalog, err
+	}
+	return catalog, nil
+}
+
+// GetCatalog returns the model catalog for all configured providers,
+// refreshing any provider whose cached list is missing or older than the
+// configured TTL. With refresh set every provider is fetched again.
+func GetCatalog(cfg *Config, refresh bool) (*Catalog, error) {
+	catalog, err := LoadCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := ModelCacheTTL(cfg)
+	var stale []Provider
+	for _, provider := range ConfiguredProviders(cfg) {
+		entry := catalog.Providers[provider]
+		if refresh || entry == nil || time.Since(entry.FetchedAt) > ttl {
+			stale = append(stale, provider)
+		}
+	}
+
+	if len(stale) == 0 {
+		return catalog, nil
+	}
+	return RefreshCatalog(cfg, stale...)
+}
+
+// Models returns the cached models of a provider.
+func (c *Catalog) Models(provider Provider) []ModelInfo {
+	if entry := c.Providers[provider]; entry != nil {
+		return entry.Models
+	}
+	return nil
+}
+
+// Lookup returns every cached model with the given ID, across providers.
+func (c *Catalog) Lookup(id string) []ModelInfo {
+	var matches []ModelInfo
+	for _, provider := range Providers {
+		for _, m := range c.Models(provider) {
+			if m.ID == id {
+				matches = append(matches, m)
+			}
+		}
+	}
+	return matches
+}
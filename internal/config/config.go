@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -20,6 +21,61 @@ type SystemPrompt struct {
 type Template struct {
 	Title   string `yaml:"title"`
 	Content string `yaml:"content"`
+	// Syntax is "go" for text/template templates. Other templates use
+	// [name] placeholders.
+	Syntax string `yaml:"syntax,omitempty"`
+}
+
+// GenerationParams are sampling parameters applied to requests for a model.
+type GenerationParams struct {
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	TopP        *float64 `yaml:"top_p,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty"`
+	Seed        *int     `yaml:"seed,omitempty"`
+	Stop        []string `yaml:"stop,omitempty"`
+}
+
+// CacheConfig controls the on-disk response cache.
+type CacheConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	TTL       string `yaml:"ttl,omitempty"`
+	MaxSizeMB int    `yaml:"max_size_mb,omitempty"`
+}
+
+// OllamaConfig holds Ollama-specific settings sent with every Ollama
+// request.
+type OllamaConfig struct {
+	NumCtx    int    `yaml:"num_ctx,omitempty"`
+	NumGPU    *int   `yaml:"num_gpu,omitempty"`
+	KeepAlive string `yaml:"keep_alive,omitempty"`
+	// Options are passed through as Ollama model options, e.g. num_thread
+	Options map[string]interface{} `yaml:"options,omitempty"`
+	// AutoPull pulls a missing model before retrying the request; on by
+	// default
+	AutoPull *bool `yaml:"auto_pull,omitempty"`
+}
+
+// CommandSafety holds rules for the shell commands suggest runs on the
+// model's behalf. Rules are regular expressions matched against the whole
+// command and each simple command in it.
+type CommandSafety struct {
+	// Allow lists commands that are run without risk warnings
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists commands that are never run
+	Deny []string `yaml:"deny,omitempty"`
+}
+
+// MCPServer is a Model Context Protocol server whose tools are offered to
+// the model. Either Command, to start the server and talk to it over stdio,
+// or URL, for a server already listening over HTTP, must be set.
+type MCPServer struct {
+	Name     string            `yaml:"name"`
+	Command  string            `yaml:"command,omitempty"`
+	Args     []string          `yaml:"args,omitempty"`
+	Env      map[string]string `yaml:"env,omitempty"`
+	URL      string            `yaml:"url,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Disabled bool              `yaml:"disabled,omitempty"`
 }
 
 type Config struct {
@@ -35,11 +91,28 @@ type Config struct {
 	ModelAliases   map[string]string `yaml:"model_aliases"`
 	Templates      []Template        `yaml:"templates"`
 	Username       string            `yaml:"username"`
+	ModelCacheTTL  string            `yaml:"model_cache_ttl,omitempty"`
+	FallbackModels []string          `yaml:"fallback_models,omitempty"`
+	Cache          CacheConfig       `yaml:"cache,omitempty"`
+	// ModelParams holds default generation parameters keyed by model ID or
+	// "provider/model"
+	ModelParams map[string]GenerationParams `yaml:"model_params,omitempty"`
+	Ollama      OllamaConfig                `yaml:"ollama,omitempty"`
+	MCPServers  []MCPServer                 `yaml:"mcp_servers,omitempty"`
+	// RateLimits caps the requests per minute sent to each provider by
+	// batch runs, keyed by provider name
+	RateLimits    map[string]int `yaml:"rate_limits,omitempty"`
+	CommandSafety CommandSafety  `yaml:"command_safety,omitempty"`
+	// CmdModel is the default model of 'suggest cmd', in place of Model
+	CmdModel string `yaml:"cmd_model,omitempty"`
+	// CmdSystemPrompt replaces the built-in system prompt of 'suggest cmd'
+	CmdSystemPrompt string `yaml:"cmd_system_prompt,omitempty"`
 }
 
 type ModelResponse struct {
 	Data []struct {
-		ID string `json:"id"`
+		ID            string `json:"id"`
+		ContextWindow int    `json:"context_window"`
 	} `json:"data"`
 }
 
@@ -53,6 +126,13 @@ const (
 	ProviderAll    Provider = "all"
 )
 
+// Providers lists every chat provider suggest can talk to.
+var Providers = []Provider{ProviderOpenAI, ProviderGroq, ProviderGemini, ProviderOllama}
+
+// httpClient is used for model listing so an unreachable provider does not
+// hang the CLI.
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
 func migrateConfig(cfg *Config) {
 	if cfg.Templates == nil {
 		cfg.Templates = []Template{}
@@ -80,13 +160,47 @@ func migrateConfig(cfg *Config) {
 	cfg.SystemPrompts = newPrompts
 }
 
-func LoadConfig() (*Config, error) {
+// ConfigPath returns the location of the config file.
+func ConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "suggest", "config.yml"), nil
+}
+
+// CacheDir returns the directory used for cached data such as the model
+// catalog.
+func CacheDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".cache", "suggest"), nil
+}
+
+// ConfigExists reports whether a config file has already been written.
+func ConfigExists() (bool, error) {
+	configPath, err := ConfigPath()
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(configPath)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func LoadConfig() (*Config, error) {
+	configPath, err := ConfigPath()
 	if err != nil {
 		return nil, err
 	}
 
-	configPath := filepath.Join(homeDir, ".config", "suggest", "config.yml")
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -111,12 +225,11 @@ func LoadConfig() (*Config, error) {
 }
 
 func SaveConfig(config *Config) error {
-	homeDir, err := os.UserHomeDir()
+	configPath, err := ConfigPath()
 	if err != nil {
 		return err
 	}
 
-	configPath := filepath.Join(homeDir, ".config", "suggest", "config.yml")
 	err = os.MkdirAll(filepath.Dir(configPath), os.ModePerm)
 	if err != nil {
 		return err
@@ -130,44 +243,46 @@ func SaveConfig(config *Config) error {
 	return os.WriteFile(configPath, data, 0644)
 }
 
+// DetermineModelProvider returns the provider serving model, or an empty
+// string if it cannot be resolved. See ResolveModel.
 func DetermineModelProvider(model string, config *Config) string {
-	if strings.HasPrefix(model, "gpt-") {
-		return "openai"
-	}
-	if strings.HasPrefix(model, "mixtral-") || 
-		strings.HasPrefix(model, "llama-") ||
-		strings.HasPrefix(model, "moonshotai/") ||
-		strings.HasPrefix(model, "qwen/") ||
-		strings.HasPrefix(model, "groq/") {
-		return "groq"
-	}
-	if strings.HasPrefix(model, "gemini-") {
-		return "gemini"
-	}
-	if strings.Contains(model, ":") ||
-		strings.HasPrefix(model, "llama2") ||
-		strings.HasPrefix(model, "codellama") ||
-		strings.HasPrefix(model, "mistral") {
-		return "ollama"
+	provider, _, err := ResolveModel(model, config)
+	if err != nil {
+		return ""
 	}
-	return ""
+	return string(provider)
 }
 
 // FetchModels fetches available models from a provider
 func FetchModels(provider Provider, cfg *Config) ([]string, error) {
+	infos, err := FetchModelInfo(provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var models []string
+	for _, info := range infos {
+		models = append(models, info.ID)
+	}
+	return models, nil
+}
+
+// FetchModelInfo fetches available models from a provider along with
+// whatever metadata the provider reports about them.
+func FetchModelInfo(provider Provider, cfg *Config) ([]ModelInfo, error) {
 	switch provider {
 	case ProviderOpenAI:
 		if cfg.OpenAIAPIKey != "" {
-			return fetchModels("https://api.openai.com/v1/models", cfg.OpenAIAPIKey)
+			return fetchModels(provider, "https://api.openai.com/v1/models", cfg.OpenAIAPIKey)
 		}
 	case ProviderGroq:
 		if cfg.GroqAPIKey != "" {
-			return fetchModels("https://api.groq.com/openai/v1/models", cfg.GroqAPIKey)
+			return fetchModels(provider, "https://api.groq.com/openai/v1/models", cfg.GroqAPIKey)
 		}
 	case ProviderGemini:
 		if cfg.GeminiAPIKey != "" {
-			url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models?key=%s", cfg.GeminiAPIKey)
-			resp, err := http.Get(url)
+			url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models?key=%s&pageSize=1000", cfg.GeminiAPIKey)
+			resp, err := httpClient.Get(url)
 			if err != nil {
 				return nil, fmt.Errorf("error fetching Gemini models: %w", err)
 			}
@@ -180,7 +295,8 @@ func FetchModels(provider Provider, cfg *Config) ([]string, error) {
 
 			var geminiResp struct {
 				Models []struct {
-					Name                      string   `json:"name"`
+					Name                       string   `json:"name"`
+					InputTokenLimit            int      `json:"inputTokenLimit"`
 					SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
 				} `json:"models"`
 			}
@@ -189,12 +305,15 @@ func FetchModels(provider Provider, cfg *Config) ([]string, error) {
 				return nil, fmt.Errorf("error parsing Gemini models: %w", err)
 			}
 
-			var models []string
+			var models []ModelInfo
 			for _, model := range geminiResp.Models {
 				for _, method := range model.SupportedGenerationMethods {
 					if method == "generateContent" {
-						name := strings.TrimPrefix(model.Name, "models/")
-						models = append(models, name)
+						models = append(models, ModelInfo{
+							ID:            strings.TrimPrefix(model.Name, "models/"),
+							Provider:      provider,
+							ContextLength: model.InputTokenLimit,
+						})
 						break
 					}
 				}
@@ -206,8 +325,8 @@ func FetchModels(provider Provider, cfg *Config) ([]string, error) {
 		if host == "" {
 			host = "http://localhost:11434"
 		}
-		
-		resp, err := http.Get(fmt.Sprintf("%s/api/tags", host))
+
+		resp, err := httpClient.Get(fmt.Sprintf("%s/api/tags", host))
 		if err != nil {
 			return nil, fmt.Errorf("error fetching Ollama models: %w", err)
 		}
@@ -228,24 +347,26 @@ func FetchModels(provider Provider, cfg *Config) ([]string, error) {
 			return nil, fmt.Errorf("error parsing Ollama models: %w", err)
 		}
 
-		var models []string
+		var models []ModelInfo
 		for _, model := range ollamaResp.Models {
-			models = append(models, model.Name)
+			models = append(models, ModelInfo{
+				ID:       model.Name,
+				Provider: provider,
+			})
 		}
 		return models, nil
 	}
 	return nil, fmt.Errorf("no API key set for provider %s", provider)
 }
 
-func fetchModels(url, apiKey string) ([]string, error) {
+func fetchModels(provider Provider, url, apiKey string) ([]ModelInfo, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -261,9 +382,13 @@ func fetchModels(url, apiKey string) ([]string, error) {
 		return nil, err
 	}
 
-	var models []string
+	var models []ModelInfo
 	for _, model := range modelResponse.Data {
-		models = append(models, model.ID)
+		models = append(models, ModelInfo{
+			ID:            model.ID,
+			Provider:      provider,
+			ContextLength: model.ContextWindow,
+		})
 	}
 
 	return models, nil
@@ -0,0 +1,113 @@
+package config
+
+import "time"
+
+// DefaultSystemPrompts returns the system prompts shipped with a fresh config.
+func DefaultSystemPrompts() []SystemPrompt {
+	return []SystemPrompt{
+		{
+			Title:   "Helpful Assistant",
+			Content: "You are a helpful assistant. You provide clear, accurate, and concise responses. When discussing code, you use markdown formatting and include helpful comments.",
+		},
+		{
+			Title:   "Programming Assistant",
+			Content: "You are a programming assistant. You help write, explain, and debug code.",
+		},
+		{
+			Title:   "Technical Writer",
+			Content: "You are a technical writer. You help create clear documentation and explanations.",
+		},
+	}
+}
+
+// DefaultTemplates returns the templates shipped with a fresh config.
+func DefaultTemplates() []Template {
+	return []Template{
+		{
+			Title:   "Code Function",
+			Content: "Write a [language] function that [task]",
+		},
+		{
+			Title:   "Code Review",
+			Content: "Review this [language] code:\n[code]",
+		},
+	}
+}
+
+// MergeDefaults adds any default system prompts and templates whose titles
+// are missing from cfg. Existing entries are never modified. It returns the
+// titles of the prompts and templates that were added.
+func MergeDefaults(cfg *Config) (addedPrompts, addedTemplates []string) {
+	migrateConfig(cfg)
+
+	promptTitles := make(map[string]bool)
+	for _, p := range cfg.SystemPrompts {
+		promptTitles[p.Title] = true
+	}
+	for _, p := range DefaultSystemPrompts() {
+		if !promptTitles[p.Title] {
+			cfg.SystemPrompts = append(cfg.SystemPrompts, p)
+			addedPrompts = append(addedPrompts, p.Title)
+		}
+	}
+
+	templateTitles := make(map[string]bool)
+	for _, t := range cfg.Templates {
+		templateTitles[t.Title] = true
+	}
+	for _, t := range DefaultTemplates() {
+		if !templateTitles[t.Title] {
+			cfg.Templates = append(cfg.Templates, t)
+			addedTemplates = append(addedTemplates, t.Title)
+		}
+	}
+
+	return addedPrompts, addedTemplates
+}
+
+// Response cache defaults, used when the cache section leaves them unset.
+const (
+	DefaultResponseCacheTTL    = 24 * time.Hour
+	DefaultResponseCacheSizeMB = 100
+)
+
+// ResponseCacheTTL returns how long cached responses stay valid.
+func ResponseCacheTTL(cfg *Config) time.Duration {
+	if cfg.Cache.TTL != "" {
+		if ttl, err := time.ParseDuration(cfg.Cache.TTL); err == nil {
+			return ttl
+		}
+	}
+	return DefaultResponseCacheTTL
+}
+
+// ResponseCacheMaxBytes returns the size limit of the response cache.
+func ResponseCacheMaxBytes(cfg *Config) int64 {
+	sizeMB := cfg.Cache.MaxSizeMB
+	if sizeMB <= 0 {
+		sizeMB = DefaultResponseCacheSizeMB
+	}
+	return int64(sizeMB) * 1024 * 1024
+}
+
+// OllamaAutoPull reports whether missing Ollama models are pulled
+// automatically.
+func OllamaAutoPull(cfg *Config) bool {
+	return cfg.Ollama.AutoPull == nil || *cfg.Ollama.AutoPull
+}
+
+// OllamaOptions returns the model options from the ollama section of the
+// config, with num_ctx and num_gpu taking precedence over the options map.
+func OllamaOptions(cfg *Config) map[string]interface{} {
+	options := make(map[string]interface{})
+	for k, v := range cfg.Ollama.Options {
+		options[k] = v
+	}
+	if cfg.Ollama.NumCtx > 0 {
+		options["num_ctx"] = cfg.Ollama.NumCtx
+	}
+	if cfg.Ollama.NumGPU != nil {
+		options["num_gpu"] = *cfg.Ollama.NumGPU
+	}
+	return options
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AmbiguousModelError is returned when a model name is offered by more than
+// one configured provider and no provider was given explicitly.
+type AmbiguousModelError struct {
+	Model      string
+	Candidates []ModelInfo
+}
+
+func (e *AmbiguousModelError) Error() string {
+	var names []string
+	for _, c := range e.Candidates {
+		names = append(names, fmt.Sprintf("%s/%s", c.Provider, c.ID))
+	}
+	return fmt.Sprintf("model '%s' is available from multiple providers, use one of: %s", e.Model, strings.Join(names, ", "))
+}
+
+// SplitProviderModel splits an explicit "provider/model" name. ok is false
+// when the prefix is not a known provider, which leaves names such as
+// "qwen/qwen3-32b" untouched.
+func SplitProviderModel(name string) (provider Provider, model string, ok bool) {
+	prefix, rest, found := strings.Cut(name, "/")
+	if !found || rest == "" {
+		return "", name, false
+	}
+	for _, p := range Providers {
+		if Provider(prefix) == p {
+			return p, rest, true
+		}
+	}
+	return "", name, false
+}
+
+// ResolveModel expands aliases and determines which provider serves a model,
+// returning the provider and the model ID to send to it.
+//
+// Names may be given explicitly as "provider/model", e.g. "groq/llama-3.3-70b".
+// Otherwise the name is looked up in the model catalog of every configured
+// provider; the catalog is refreshed once if the name is not found, and an
+// *AmbiguousModelError is returned when several providers offer it. With
+// ollama.auto_pull on, a tagged name such as "mistral:7b" that no provider
+// offers goes to Ollama, which pulls it on first use; other unknown names
+// are an error, so a misspelled model isn't pulled.
+func ResolveModel(name string, cfg *Config) (Provider, string, error) {
+	if actualModel, exists := cfg.ModelAliases[name]; exists {
+		name = actualModel
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("no model selected, use 'suggest model' to pick one")
+	}
+
+	catalog, err := GetCatalog(cfg, false)
+	if err != nil {
+		catalog = &Catalog{Providers: make(map[Provider]*ProviderCatalog)}
+	}
+
+	if provider, model, ok := SplitProviderModel(name); ok {
+		// Some model IDs start with a provider name of their own, such as
+		// Groq's "openai/gpt-oss-120b" and "groq/compound". The prefix
+		// names the provider when it offers the rest of the name, or when
+		// the full name isn't a known model; "groq/openai/gpt-oss-120b"
+		// always works.
+		if hasModel(catalog, provider, model) {
+			return provider, model, nil
+		}
+		if matches := lookupConfigured(catalog, name, cfg); len(matches) == 1 {
+			return matches[0].Provider, matches[0].ID, nil
+		} else if len(matches) > 1 {
+			return "", "", &AmbiguousModelError{Model: name, Candidates: matches}
+		}
+		return provider, model, nil
+	}
+
+	matches := lookupConfigured(catalog, name, cfg)
+	if len(matches) == 0 {
+		if refreshed, err := RefreshCatalog(cfg, ConfiguredProviders(cfg)...); err == nil {
+			catalog = refreshed
+			matches = lookupConfigured(catalog, name, cfg)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0].Provider, matches[0].ID, nil
+	case 0:
+		// Without any model lists to go on, fall back to guessing from the name
+		if !catalogAvailable(catalog, cfg) {
+			if provider := guessProvider(name); provider != "" {
+				return provider, name, nil
+			}
+		}
+		if OllamaAutoPull(cfg) && strings.Contains(name, ":") {
+			return ProviderOllama, name, nil
+		}
+		return "", "", fmt.Errorf("model '%s' not found on any configured provider, run 'suggest models' to see what is available", name)
+	default:
+		return "", "", &AmbiguousModelError{Model: name, Candidates: matches}
+	}
+}
+
+// lookupConfigured finds a model in the catalog of every configured provider.
+// Ollama names without a tag match the ":latest" tag.
+func lookupConfigured(catalog *Catalog, name string, cfg *Config) []ModelInfo {
+	var matches []ModelInfo
+	for _, provider := range ConfiguredProviders(cfg) {
+		for _, m := range catalog.Models(provider) {
+			if m.ID == name || (provider == ProviderOllama && !strings.Contains(name, ":") && m.ID == name+":latest") {
+				matches = append(matches, m)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// hasModel reports whether the catalog of provider lists the model ID.
+func hasModel(catalog *Catalog, provider Provider, id string) bool {
+	for _, m := range catalog.Models(provider) {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// catalogAvailable reports whether any configured provider has a model list.
+func catalogAvailable(catalog *Catalog, cfg *Config) bool {
+	for _, provider := range ConfiguredProviders(cfg) {
+		if len(catalog.Models(provider)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// guessProvider infers a provider from well-known model name patterns. It is
+// only used when no provider's model list can be fetched or read from cache.
+func guessProvider(model string) Provider {
+	switch {
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "o1"),
+		strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+		return ProviderOpenAI
+	case strings.HasPrefix(model, "gemini-"):
+		return ProviderGemini
+	case strings.Contains(model, ":"):
+		return ProviderOllama
+	case strings.HasPrefix(model, "mixtral-"), strings.HasPrefix(model, "llama-"),
+		strings.HasPrefix(model, "moonshotai/"), strings.HasPrefix(model, "qwen/"),
+		strings.HasPrefix(model, "meta-llama/"), strings.HasPrefix(model, "deepseek-"):
+		return ProviderGroq
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newResolveConfig returns a config whose only provider is a stub Ollama
+// server offering models, with the catalog cached in a temporary home.
+func newResolveConfig(t *testing.T, models ...string) *Config {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		var resp struct {
+			Models []map[string]string `json:"models"`
+		}
+		for _, m := range models {
+			resp.Models = append(resp.Models, map[string]string{"name": m})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return &Config{OllamaHost: ts.URL}
+}
+
+func TestResolveModelOllama(t *testing.T) {
+	off := false
+	tests := []struct {
+		name     string
+		model    string
+		noPull   bool
+		want     string
+		notFound bool
+	}{
+		{name: "listed", model: "llama3.1:8b", want: "llama3.1:8b"},
+		{name: "listed without tag", model: "llama3.1", want: "llama3.1:latest"},
+		{name: "explicit provider", model: "ollama/mistral", want: "mistral"},
+		{name: "tagged", model: "mistral:7b", want: "mistral:7b"},
+		{name: "tagged without auto-pull", model: "mistral:7b", noPull: true, notFound: true},
+		{name: "misspelled", model: "lama3.1", notFound: true},
+		{name: "other provider's name", model: "gpt-4oo", notFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newResolveConfig(t, "llama3.1:8b", "llama3.1:latest")
+			if tt.noPull {
+				cfg.Ollama.AutoPull = &off
+			}
+
+			provider, model, err := ResolveModel(tt.model, cfg)
+			if tt.notFound {
+				if err == nil || !strings.Contains(err.Error(), "not found on any configured provider") {
+					t.Fatalf("ResolveModel(%q) = %s/%s, %v; want a not found error", tt.model, provider, model, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveModel(%q): %v", tt.model, err)
+			}
+			if provider != ProviderOllama || model != tt.want {
+				t.Errorf("ResolveModel(%q) = %s/%s, want ollama/%s", tt.model, provider, model, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveModelProviderPrefixedID(t *testing.T) {
+	cfg := newResolveConfig(t)
+	cfg.OpenAIAPIKey = "key"
+	cfg.GroqAPIKey = "key"
+
+	// Fresh cached lists, so nothing is fetched from the real APIs
+	now := time.Now()
+	catalog := &Catalog{Providers: map[Provider]*ProviderCatalog{
+		ProviderOpenAI: {FetchedAt: now, Models: []ModelInfo{{ID: "gpt-4o", Provider: ProviderOpenAI}}},
+		ProviderGroq:   {FetchedAt: now, Models: []ModelInfo{{ID: "openai/gpt-oss-120b", Provider: ProviderGroq}}},
+		ProviderOllama: {FetchedAt: now},
+	}}
+	if err := SaveCatalog(catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"openai/gpt-4o":            "openai/gpt-4o",
+		"openai/gpt-oss-120b":      "groq/openai/gpt-oss-120b",
+		"groq/openai/gpt-oss-120b": "groq/openai/gpt-oss-120b",
+		"gpt-4o":                   "openai/gpt-4o",
+	}
+	for name, want := range tests {
+		provider, model, err := ResolveModel(name, cfg)
+		if err != nil {
+			t.Errorf("ResolveModel(%q): %v", name, err)
+			continue
+		}
+		if got := string(provider) + "/" + model; got != want {
+			t.Errorf("ResolveModel(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
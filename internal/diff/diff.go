@@ -0,0 +1,259 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around a change.
+const DefaultContext = 3
+
+// maxCells bounds the size of the LCS table; larger changes are shown as
+// one block of removed lines followed by one block of added lines.
+const maxCells = 25_000_000
+
+// Op is a single line of a diff: ' ' for unchanged, '-' for removed and '+'
+// for added lines.
+type Op struct {
+	Kind byte
+	Line string
+}
+
+// Hunk is a group of nearby changes with surrounding context lines.
+type Hunk struct {
+	// OldIndex is the 0-based index of the hunk's first line in the old text
+	OldIndex int
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Ops      []Op
+}
+
+// SplitLines splits text into lines without their line endings.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// JoinLines is the inverse of SplitLines, ending the text with a newline.
+func JoinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Lines computes a line diff between a and b.
+func Lines(a, b []string) []Op {
+	// Strip the common prefix and suffix, which keeps the LCS table small
+	// for typical edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Op
+	for _, line := range a[:prefix] {
+		ops = append(ops, Op{' ', line})
+	}
+	ops = append(ops, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Op{' ', line})
+	}
+	return ops
+}
+
+func lcs(a, b []string) []Op {
+	var ops []Op
+	if len(a)*len(b) > maxCells {
+		for _, line := range a {
+			ops = append(ops, Op{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, Op{'+', line})
+		}
+		return ops
+	}
+
+	// table[i][j] is the length of the LCS of a[i:] and b[j:]
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{' ', a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			ops = append(ops, Op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, Op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, Op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, Op{'+', b[j]})
+	}
+	return ops
+}
+
+// Hunks groups the changes of a diff into hunks with the given number of
+// context lines.
+func Hunks(ops []Op, context int) []Hunk {
+	var hunks []Hunk
+	oldLine, newLine := 0, 0
+
+	i := 0
+	for i < len(ops) {
+		if ops[i].Kind == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// Start a hunk up to context lines before this change
+		start := max(0, i-context)
+		hunk := Hunk{OldIndex: oldLine - (i - start)}
+		newIndex := newLine - (i - start)
+
+		// Extend it while the next change is within 2*context lines
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(run, end+context)
+				break
+			}
+			end = run
+		}
+
+		hunk.Ops = append([]Op(nil), ops[start:end]...)
+		for _, op := range hunk.Ops {
+			if op.Kind != '+' {
+				hunk.OldLines++
+			}
+			if op.Kind != '-' {
+				hunk.NewLines++
+			}
+		}
+		hunk.OldStart = startLine(hunk.OldIndex, hunk.OldLines)
+		hunk.NewStart = startLine(newIndex, hunk.NewLines)
+		hunks = append(hunks, hunk)
+
+		for _, op := range ops[i:end] {
+			if op.Kind != '+' {
+				oldLine++
+			}
+			if op.Kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return hunks
+}
+
+// startLine converts a 0-based index to the 1-based line number used in hunk
+// headers, where an empty range refers to the line before it.
+func startLine(index, lines int) int {
+	if lines == 0 {
+		return index
+	}
+	return index + 1
+}
+
+// Header returns the "@@ -a,b +c,d @@" line of the hunk.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header() + "\n")
+	for _, op := range h.Ops {
+		b.WriteByte(op.Kind)
+		b.WriteString(op.Line + "\n")
+	}
+	return b.String()
+}
+
+// NewText returns the lines the hunk leaves in place of its old lines.
+func (h Hunk) NewText() []string {
+	var lines []string
+	for _, op := range h.Ops {
+		if op.Kind != '-' {
+			lines = append(lines, op.Line)
+		}
+	}
+	return lines
+}
+
+// Replace changes the new side of the hunk to lines, keeping its old side.
+func (h *Hunk) Replace(lines []string) {
+	var ops []Op
+	for _, op := range h.Ops {
+		if op.Kind != '+' {
+			ops = append(ops, Op{'-', op.Line})
+		}
+	}
+	for _, line := range lines {
+		ops = append(ops, Op{'+', line})
+	}
+	h.Ops = ops
+	h.NewLines = len(lines)
+}
+
+// Unified formats hunks as a unified diff of path.
+func Unified(path string, hunks []Hunk) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	for _, h := range hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Apply applies hunks, which must come from the same diff of old and be in
+// order, and returns the resulting lines. Hunks left out keep the old lines.
+func Apply(old []string, hunks []Hunk) []string {
+	var result []string
+	pos := 0
+	for _, h := range hunks {
+		result = append(result, old[pos:h.OldIndex]...)
+		result = append(result, h.NewText()...)
+		pos = h.OldIndex + h.OldLines
+	}
+	return append(result, old[pos:]...)
+}
@@ -0,0 +1,199 @@
+// Package jsonschema validates decoded JSON values against the commonly used
+// subset of JSON Schema: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum and maximum.
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks value, as decoded by encoding/json, against schema and
+// returns a description of every violation. An empty result means the value
+// is valid.
+func Validate(schema map[string]interface{}, value interface{}) []string {
+	var errs []string
+	validate(schema, value, "$", &errs)
+	return errs
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string, errs *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := stringList(t)
+		if len(types) > 0 && !matchesType(value, types) {
+			fail("expected %s, got %s", strings.Join(types, " or "), typeName(value))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %v is not one of %v", value, enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && !equal(c, value) {
+		fail("value %v is not %v", value, c)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(schema, v, path, errs)
+
+	case []interface{}:
+		if n, ok := number(schema["minItems"]); ok && float64(len(v)) < n {
+			fail("expected at least %v items, got %d", n, len(v))
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(v)) > n {
+			fail("expected at most %v items, got %d", n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n, ok := number(schema["minLength"]); ok && float64(length) < n {
+			fail("expected at least %v characters, got %d", n, length)
+		}
+		if n, ok := number(schema["maxLength"]); ok && float64(length) > n {
+			fail("expected at most %v characters, got %d", n, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("%q does not match %q", v, pattern)
+			}
+		}
+
+	case float64:
+		if n, ok := number(schema["minimum"]); ok && v < n {
+			fail("%v is less than the minimum %v", v, n)
+		}
+		if n, ok := number(schema["maximum"]); ok && v > n {
+			fail("%v is greater than the maximum %v", v, n)
+		}
+	}
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, errs *[]string) {
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "." + k
+		if prop, ok := properties[k].(map[string]interface{}); ok {
+			validate(prop, obj[k], child, errs)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				*errs = append(*errs, fmt.Sprintf("%s: unexpected property", child))
+			}
+		case map[string]interface{}:
+			validate(extra, obj[k], child, errs)
+		}
+	}
+}
+
+func matchesType(value interface{}, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if f, ok := value.(float64); ok && f == float64(int64(f)) {
+				return true
+			}
+		case typeName(value):
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// equal compares schema values, which may hold integers, with decoded JSON
+// values, which hold float64s.
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func stringList(v interface{}) []string {
+	switch s := v.(type) {
+	case string:
+		return []string{s}
+	case []string:
+		return s
+	case []interface{}:
+		var out []string
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
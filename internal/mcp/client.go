@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// transport carries JSON-RPC messages to a server.
+type transport interface {
+	// request sends msg and waits for the response with the same ID
+	request(ctx context.Context, msg *Message) (*Message, error)
+	notify(ctx context.Context, msg *Message) error
+	close() error
+}
+
+// Client talks to a single MCP server.
+type Client struct {
+	transport transport
+	nextID    atomic.Int64
+
+	// Server is filled in by Initialize
+	Server       Implementation
+	Capabilities map[string]interface{}
+	Instructions string
+}
+
+// NewStdioClient starts command and talks to it over its stdin and stdout.
+// env is added to the current environment.
+func NewStdioClient(command string, args []string, env map[string]string) (*Client, error) {
+	t, err := newStdioTransport(command, args, env)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{transport: t}, nil
+}
+
+// NewHTTPClient talks to a server using the streamable HTTP transport,
+// sending headers with every request.
+func NewHTTPClient(url string, headers map[string]string) *Client {
+	return &Client{transport: newHTTPTransport(url, headers)}
+}
+
+// Initialize performs the protocol handshake. It must be called before any
+// other method.
+func (c *Client) Initialize(ctx context.Context, clientInfo Implementation) error {
+	var result InitializeResult
+	err := c.call(ctx, "initialize", InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      clientInfo,
+	}, &result)
+	if err != nil {
+		return err
+	}
+
+	c.Server = result.ServerInfo
+	c.Capabilities = result.Capabilities
+	c.Instructions = result.Instructions
+
+	return c.transport.notify(ctx, &Message{
+		JSONRPC: "2.0",
+		Method:  "notifications/initialized",
+	})
+}
+
+// HasCapability reports whether the server announced a capability such as
+// "tools" or "resources".
+func (c *Client) HasCapability(name string) bool {
+	_, ok := c.Capabilities[name]
+	return ok
+}
+
+// ListTools returns every tool of the server, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var result ListToolsResult
+		if err := c.call(ctx, "tools/list", cursorParams(cursor), &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", CallToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns every resource of the server, following pagination.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	cursor := ""
+	for {
+		var result ListResourcesResult
+		if err := c.call(ctx, "resources/list", cursorParams(cursor), &result); err != nil {
+			return nil, err
+		}
+		resources = append(resources, result.Resources...)
+		if result.NextCursor == "" {
+			return resources, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	var result ReadResourceResult
+	if err := c.call(ctx, "resources/read", ReadResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// Close shuts down the connection, stopping the server process for stdio
+// servers.
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("error marshaling params: %w", err)
+	}
+
+	id := c.nextID.Add(1)
+	resp, err := c.transport.request(ctx, &Message{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(strconv.FormatInt(id, 10)),
+		Method:  method,
+		Params:  data,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("error decoding %s result: %w", method, err)
+	}
+	return nil
+}
+
+func cursorParams(cursor string) map[string]string {
+	if cursor == "" {
+		return map[string]string{}
+	}
+	return map[string]string{"cursor": cursor}
+}
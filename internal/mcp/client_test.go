@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubEnv makes the test binary run the stub server on stdio instead of the
+// tests, so the stdio client can start it as a subprocess.
+const stubEnv = "SUGGEST_MCP_STUB"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubEnv) == "1" {
+		if err := newStubServer().Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// newStubServer offers an echo tool and a tool that always fails.
+func newStubServer() *Server {
+	s := NewServer(Implementation{Name: "stub", Version: "1.0.0"})
+	s.Instructions = "Test server"
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echo the text back",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+			"required":   []string{"text"},
+		},
+	}, func(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
+		return TextResult(fmt.Sprint(args["text"])), nil
+	})
+	s.AddTool(Tool{
+		Name:        "fail",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
+		return nil, errors.New("tool failed")
+	})
+	return s
+}
+
+func TestStdioClient(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewStdioClient(exe, []string{"-test.run=^$"}, map[string]string{stubEnv: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	exerciseClient(t, client)
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := newStubServer()
+	const sessionID = "session-1"
+
+	var mu sync.Mutex
+	var sessions []string
+	var deleted bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			deleted = r.Header.Get(sessionHeader) == sessionID
+			mu.Unlock()
+			return
+		}
+
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		sessions = append(sessions, r.Header.Get(sessionHeader))
+		mu.Unlock()
+
+		if msg.Method == "initialize" {
+			w.Header().Set(sessionHeader, sessionID)
+		}
+		if msg.IsNotification() {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		data, _ := json.Marshal(server.handle(r.Context(), &msg))
+		if msg.Method != "tools/call" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+			return
+		}
+		// Answer tool calls as an event stream, with a notification first
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+	}))
+	defer ts.Close()
+
+	client := NewHTTPClient(ts.URL, map[string]string{"Authorization": "Bearer secret"})
+	exerciseClient(t, client)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sessions[0] != "" {
+		t.Errorf("initialize sent session %q, want none", sessions[0])
+	}
+	for i, s := range sessions[1:] {
+		if s != sessionID {
+			t.Errorf("request %d sent session %q, want %q", i+1, s, sessionID)
+		}
+	}
+	if !deleted {
+		t.Error("Close did not delete the session")
+	}
+}
+
+func TestHTTPClientStatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	err := NewHTTPClient(ts.URL, nil).Initialize(context.Background(), Implementation{Name: "test"})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Initialize error = %v, want status 401", err)
+	}
+}
+
+// exerciseClient runs the handshake, lists the tools and calls them.
+func exerciseClient(t *testing.T, client *Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.Initialize(ctx, Implementation{Name: "test", Version: "0.0.1"}); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if client.Server.Name != "stub" || client.Server.Version != "1.0.0" {
+		t.Errorf("Server = %+v, want stub 1.0.0", client.Server)
+	}
+	if client.Instructions != "Test server" {
+		t.Errorf("Instructions = %q", client.Instructions)
+	}
+	if !client.HasCapability("tools") {
+		t.Error("tools capability missing")
+	}
+	if client.HasCapability("resources") {
+		t.Error("resources capability announced without resources")
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "echo,fail" {
+		t.Fatalf("tools = %v, want [echo fail]", names)
+	}
+	if tools[0].InputSchema["type"] != "object" {
+		t.Errorf("echo schema = %v", tools[0].InputSchema)
+	}
+
+	result, err := client.CallTool(ctx, "echo", map[string]interface{}{"text": "hello"})
+	if err != nil {
+		t.Fatalf("CallTool echo: %v", err)
+	}
+	if result.IsError || result.Text() != "hello" {
+		t.Errorf("echo result = %+v, want hello", result)
+	}
+
+	result, err = client.CallTool(ctx, "fail", nil)
+	if err != nil {
+		t.Fatalf("CallTool fail: %v", err)
+	}
+	if !result.IsError || result.Text() != "tool failed" {
+		t.Errorf("fail result = %+v, want an error result", result)
+	}
+
+	_, err = client.CallTool(ctx, "missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("CallTool missing error = %v, want invalid params", err)
+	}
+}
@@ -0,0 +1,156 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// sessionHeader carries the session ID assigned by the server.
+const sessionHeader = "Mcp-Session-Id"
+
+// httpTransport implements the streamable HTTP transport: every message is
+// POSTed to the server, which answers with JSON or an event stream.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func newHTTPTransport(url string, headers map[string]string) *httpTransport {
+	return &httpTransport{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{},
+	}
+}
+
+func (t *httpTransport) post(ctx context.Context, msg *Message) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range t.headers {
+		httpReq.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		httpReq.Header.Set(sessionHeader, t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("MCP server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) request(ctx context.Context, msg *Message) (*Message, error) {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var reply Message
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			return nil, fmt.Errorf("error decoding response: %w", err)
+		}
+		return &reply, nil
+	}
+
+	// Read events until the response to our request arrives; the server
+	// may send notifications first
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		var reply Message
+		err := json.Unmarshal([]byte(data.String()), &reply)
+		data.Reset()
+		if err == nil && reply.IsResponse() && string(reply.ID) == string(msg.ID) {
+			return &reply, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading event stream: %w", err)
+	}
+	var reply Message
+	if err := json.Unmarshal([]byte(data.String()), &reply); err == nil && string(reply.ID) == string(msg.ID) {
+		return &reply, nil
+	}
+	return nil, fmt.Errorf("event stream ended without a response to %s", msg.Method)
+}
+
+func (t *httpTransport) notify(ctx context.Context, msg *Message) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	// Let the server release the session
+	httpReq, err := http.NewRequest("DELETE", t.url, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set(sessionHeader, sessionID)
+	for k, v := range t.headers {
+		httpReq.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ToolHandler runs a tool with the arguments sent by the client.
+type ToolHandler func(ctx context.Context, args map[string]interface{}) (*CallToolResult, error)
+
+// ResourceReader returns the contents of a resource.
+type ResourceReader func(ctx context.Context) (ResourceContents, error)
+
+// Server answers MCP requests with a fixed set of tools and resources.
+type Server struct {
+	Info         Implementation
+	Instructions string
+
+	tools     []Tool
+	handlers  map[string]ToolHandler
+	resources []Resource
+	readers   map[string]ResourceReader
+
+	writeMu sync.Mutex
+}
+
+func NewServer(info Implementation) *Server {
+	return &Server{
+		Info:     info,
+		handlers: make(map[string]ToolHandler),
+		readers:  make(map[string]ResourceReader),
+	}
+}
+
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	s.tools = append(s.tools, tool)
+	s.handlers[tool.Name] = handler
+}
+
+func (s *Server) AddResource(resource Resource, reader ResourceReader) {
+	s.resources = append(s.resources, resource)
+	s.readers[resource.URI] = reader
+}
+
+// TextResult is a tool result holding a single piece of text.
+func TextResult(text string) *CallToolResult {
+	return &CallToolResult{Content: []Content{{Type: "text", Text: text}}}
+}
+
+// ErrorResult reports a failed tool call to the model rather than as a
+// protocol error.
+func ErrorResult(err error) *CallToolResult {
+	return &CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes the
+// responses to w until r is closed or ctx is done. Requests are handled
+// concurrently, so a slow tool call doesn't hold up pings.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			s.write(w, &Message{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &RPCError{Code: CodeParseError, Message: err.Error()},
+			})
+			continue
+		}
+		if msg.Method == "" || msg.IsNotification() {
+			// Responses and notifications such as notifications/initialized
+			// need no answer
+			continue
+		}
+
+		wg.Add(1)
+		go func(msg Message) {
+			defer wg.Done()
+			s.write(w, s.handle(ctx, &msg))
+		}(msg)
+	}
+	return scanner.Err()
+}
+
+func (s *Server) write(w io.Writer, msg *Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	w.Write(append(data, '\n'))
+}
+
+func (s *Server) handle(ctx context.Context, msg *Message) *Message {
+	result, err := s.dispatch(ctx, msg.Method, msg.Params)
+
+	reply := &Message{JSONRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		rpcErr, ok := err.(*RPCError)
+		if !ok {
+			rpcErr = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		}
+		reply.Error = rpcErr
+		return reply
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		reply.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		return reply
+	}
+	reply.Result = data
+	return reply
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		capabilities := map[string]interface{}{}
+		if len(s.tools) > 0 {
+			capabilities["tools"] = map[string]interface{}{}
+		}
+		if len(s.resources) > 0 {
+			capabilities["resources"] = map[string]interface{}{}
+		}
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    capabilities,
+			ServerInfo:      s.Info,
+			Instructions:    s.Instructions,
+		}, nil
+
+	case "ping":
+		return struct{}{}, nil
+
+	case "tools/list":
+		return ListToolsResult{Tools: s.tools}, nil
+
+	case "tools/call":
+		var p CallToolParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		handler, ok := s.handlers[p.Name]
+		if !ok {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool '%s'", p.Name)}
+		}
+		if p.Arguments == nil {
+			p.Arguments = map[string]interface{}{}
+		}
+		result, err := handler(ctx, p.Arguments)
+		if err != nil {
+			return ErrorResult(err), nil
+		}
+		return result, nil
+
+	case "resources/list":
+		return ListResourcesResult{Resources: s.resources}, nil
+
+	case "resources/read":
+		var p ReadResourceParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		reader, ok := s.readers[p.URI]
+		if !ok {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown resource '%s'", p.URI)}
+		}
+		contents, err := reader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ReadResourceResult{Contents: []ResourceContents{contents}}, nil
+	}
+
+	return nil, &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+}
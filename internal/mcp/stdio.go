@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStderrBytes is how much of a server's stderr is kept for error
+// messages.
+const maxStderrBytes = 4096
+
+// closeTimeout is how long a server gets to exit after its stdin is closed.
+const closeTimeout = 2 * time.Second
+
+// stdioTransport exchanges newline-delimited JSON-RPC messages with a child
+// process.
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tailBuffer
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *Message
+	err     error
+	done    chan struct{}
+}
+
+func newStdioTransport(command string, args []string, env map[string]string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &tailBuffer{max: maxStderrBytes}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting %s: %w", command, err)
+	}
+
+	t := &stdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  stderr,
+		pending: make(map[string]chan *Message),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue // Skip anything that isn't JSON-RPC, such as log lines
+		}
+
+		switch {
+		case msg.IsResponse():
+			t.mu.Lock()
+			ch, ok := t.pending[string(msg.ID)]
+			delete(t.pending, string(msg.ID))
+			t.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case msg.Method != "" && len(msg.ID) > 0:
+			// Answer requests from the server: ping is the only one a
+			// client without capabilities has to support
+			reply := &Message{JSONRPC: "2.0", ID: msg.ID}
+			if msg.Method == "ping" {
+				reply.Result = json.RawMessage("{}")
+			} else {
+				reply.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+			}
+			t.write(reply)
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = errors.New("server closed the connection")
+	}
+	if stderr := strings.TrimSpace(t.stderr.String()); stderr != "" {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+
+	t.mu.Lock()
+	t.err = err
+	t.pending = nil
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *stdioTransport) write(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) request(ctx context.Context, msg *Message) (*Message, error) {
+	ch := make(chan *Message, 1)
+	t.mu.Lock()
+	if t.pending == nil {
+		err := t.err
+		t.mu.Unlock()
+		return nil, err
+	}
+	t.pending[string(msg.ID)] = ch
+	t.mu.Unlock()
+
+	if err := t.write(msg); err != nil {
+		return nil, fmt.Errorf("error writing to server: %w", err)
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		return nil, t.err
+	case <-ctx.Done():
+		t.mu.Lock()
+		if t.pending != nil {
+			delete(t.pending, string(msg.ID))
+		}
+		t.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(ctx context.Context, msg *Message) error {
+	return t.write(msg)
+}
+
+func (t *stdioTransport) close() error {
+	// Closing stdin asks the server to exit; kill it if it doesn't
+	t.stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		t.cmd.Wait()
+		close(exited)
+	}()
+
+	select {
+	case <-exited:
+	case <-time.After(closeTimeout):
+		t.cmd.Process.Kill()
+		<-exited
+	}
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p)
+	if extra := b.buf.Len() - b.max; extra > 0 {
+		b.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
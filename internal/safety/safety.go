@@ -0,0 +1,653 @@
+// Package safety estimates how risky a shell command is before it runs. It
+// parses the command and flags destructive operations, privilege
+// escalation, downloaded code being run, network access and writes outside
+// the working directory.
+package safety
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Level is the risk of a command.
+type Level int
+
+const (
+	Safe Level = iota
+	Low
+	Medium
+	High
+)
+
+func (l Level) String() string {
+	switch l {
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	}
+	return "safe"
+}
+
+// Finding is a single reason a command is risky.
+type Finding struct {
+	Level  Level
+	Reason string
+}
+
+// Result is the analysis of a command.
+type Result struct {
+	// Level is the highest level of the findings
+	Level    Level
+	Findings []Finding
+	// Denied is the deny rule the command matched, if any
+	Denied string
+}
+
+// Analyzer checks commands, honoring allow and deny rules. Rules are
+// regular expressions. Allow rules are matched against each simple command,
+// which then isn't flagged; the rest of the command, including its pipes
+// and redirections, is still checked. Deny rules are matched against the
+// whole command and each simple command in it, and such commands must not
+// run at all.
+type Analyzer struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+	home  string
+}
+
+func NewAnalyzer(allow, deny []string) (*Analyzer, error) {
+	a := &Analyzer{}
+	a.home, _ = os.UserHomeDir()
+	for _, rule := range allow {
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow rule %q: %w", rule, err)
+		}
+		a.allow = append(a.allow, re)
+	}
+	for _, rule := range deny {
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny rule %q: %w", rule, err)
+		}
+		a.deny = append(a.deny, re)
+	}
+	return a, nil
+}
+
+var (
+	shells = set("sh", "bash", "zsh", "dash", "ksh", "fish", "csh", "tcsh")
+	// interpreters run a script read from stdin like a shell does
+	interpreters = set("python", "python3", "perl", "ruby", "node", "php")
+	// wrappers run the command in their arguments
+	wrappers = set("sudo", "doas", "env", "nohup", "nice", "time", "timeout", "command", "exec", "xargs", "watch", "stdbuf")
+	// alwaysHigh are destructive no matter their arguments
+	alwaysHigh = map[string]string{
+		"dd":       "writes raw data to files or devices",
+		"mkfs":     "formats a file system",
+		"fdisk":    "changes disk partitions",
+		"parted":   "changes disk partitions",
+		"wipefs":   "erases file system signatures",
+		"shred":    "irrecoverably overwrites files",
+		"shutdown": "shuts the machine down",
+		"reboot":   "reboots the machine",
+		"halt":     "halts the machine",
+		"poweroff": "powers the machine off",
+		"kill":     "kills processes",
+		"killall":  "kills processes",
+		"pkill":    "kills processes",
+		"crontab":  "changes scheduled jobs",
+		"iptables": "changes firewall rules",
+	}
+	network = set("curl", "wget", "scp", "rsync", "ftp", "sftp", "nc", "ncat", "telnet", "ssh", "aria2c")
+	// writers take the files they write as arguments
+	writers = set("touch", "mkdir", "tee", "truncate", "install", "ln", "chmod", "chown", "chgrp")
+	// systemDirs are directories whose contents only administrators change
+	systemDirs = []string{"/etc", "/usr", "/bin", "/sbin", "/lib", "/lib64", "/boot", "/var", "/opt", "/sys", "/proc", "/dev", "/System", "/Library"}
+	// harmless are files writing to which is always fine
+	// homeConfig are files in the home directory that run code or hold
+	// credentials
+	homeConfig = []string{".bashrc", ".bash_profile", ".bash_login", ".profile", ".zshrc", ".zshenv", ".zprofile", ".zlogin", ".config/fish", ".ssh", ".gnupg", ".aws", ".kube"}
+	harmless   = set("/dev/null", "/dev/stdout", "/dev/stderr", "/dev/tty")
+)
+
+// Analyze checks command as it would run in dir.
+func (a *Analyzer) Analyze(command, dir string) Result {
+	var r Result
+	trimmed := strings.TrimSpace(command)
+
+	if rule := match(a.deny, trimmed); rule != "" {
+		r.Denied = rule
+	}
+
+	c := &checker{analyzer: a, dir: dir, result: &r}
+	c.script(command, 0)
+	return r
+}
+
+func (r *Result) add(level Level, reason string) {
+	for _, f := range r.Findings {
+		if f.Reason == reason {
+			return
+		}
+	}
+	r.Findings = append(r.Findings, Finding{Level: level, Reason: reason})
+	if level > r.Level {
+		r.Level = level
+	}
+}
+
+type checker struct {
+	analyzer *Analyzer
+	dir      string
+	result   *Result
+}
+
+// maxScriptDepth bounds how deep scripts passed to "sh -c" are followed.
+const maxScriptDepth = 3
+
+// script parses and checks a shell script.
+func (c *checker) script(src string, depth int) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(src), "")
+	if err != nil {
+		c.result.add(Medium, "the command could not be parsed, so it wasn't checked")
+		return
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			c.redirects(n)
+		case *syntax.BinaryCmd:
+			if n.Op == syntax.Pipe || n.Op == syntax.PipeAll {
+				if name := program(firstCall(n.Y)); shells[name] || interpreters[name] {
+					c.result.add(High, fmt.Sprintf("pipes output into %s, which runs it as a script", name))
+				}
+			}
+		case *syntax.CallExpr:
+			words := wordStrings(n.Args)
+			text := strings.Join(words, " ")
+			if rule := match(c.analyzer.deny, text); rule != "" && c.result.Denied == "" {
+				c.result.Denied = rule
+			}
+			// An allow rule only covers the simple command it matches
+			if match(c.analyzer.allow, text) == "" {
+				c.call(n, words, depth)
+			}
+		}
+		return true
+	})
+}
+
+// call checks a simple command, looking through wrappers such as sudo.
+func (c *checker) call(call *syntax.CallExpr, words []string, depth int) {
+	for _, arg := range call.Args {
+		if hasSubstitution(arg) && len(words) > 0 && (shells[base(words[0])] || words[0] == "eval" || words[0] == "source" || words[0] == ".") {
+			c.result.add(High, fmt.Sprintf("%s runs the output of another command", words[0]))
+			break
+		}
+	}
+
+	for len(words) > 0 && wrappers[base(words[0])] {
+		name := base(words[0])
+		if name == "sudo" || name == "doas" {
+			c.result.add(High, "runs with root privileges ("+name+")")
+		}
+		words = skipWrapper(name, words[1:])
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	name := base(words[0])
+	args := words[1:]
+	flags, operands := splitFlags(args)
+
+	if reason, ok := alwaysHigh[name]; ok {
+		c.result.add(High, fmt.Sprintf("%s %s", name, reason))
+	}
+	if strings.HasPrefix(name, "mkfs.") {
+		c.result.add(High, fmt.Sprintf("%s formats a file system", name))
+	}
+	if network[name] {
+		c.result.add(Medium, fmt.Sprintf("%s accesses the network", name))
+		for _, path := range networkOutputs(name, args, operands) {
+			c.path(path, "writes")
+		}
+	}
+	if shells[name] {
+		// Check the script of "sh -c script" like the command itself
+		for i, arg := range args[:max(len(args)-1, 0)] {
+			if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c") {
+				if strings.HasPrefix(args[i+1], "$") {
+					c.result.add(High, fmt.Sprintf("%s -c runs a script only known when it runs", name))
+				} else if depth < maxScriptDepth {
+					c.script(args[i+1], depth+1)
+				}
+				break
+			}
+		}
+	}
+	if name == "eval" && len(args) > 0 {
+		// eval runs its arguments as a script
+		if hasExpansion(call.Args[len(call.Args)-len(args):]) {
+			c.result.add(High, "eval runs a command only known when it runs")
+		} else if depth < maxScriptDepth {
+			c.script(strings.Join(args, " "), depth+1)
+		}
+	}
+	if interpreters[name] && hasFlag(flags, "c", "e") {
+		c.result.add(Low, fmt.Sprintf("runs inline code with %s", name))
+	}
+
+	switch name {
+	case "su":
+		c.result.add(High, "runs as another user (su)")
+	case "rm", "rmdir", "unlink":
+		if name == "rm" && hasFlag(flags, "r", "R", "recursive") {
+			c.result.add(High, "deletes files recursively")
+		} else {
+			c.result.add(Medium, "deletes files")
+		}
+		for _, path := range operands {
+			c.path(path, "deletes")
+		}
+	case "mv", "cp":
+		if name == "mv" {
+			c.result.add(Low, "moves files, overwriting existing ones")
+		}
+		if len(operands) > 1 {
+			c.path(operands[len(operands)-1], "writes")
+		}
+		if name == "mv" {
+			for _, path := range operands[:max(len(operands)-1, 0)] {
+				c.path(path, "moves")
+			}
+		}
+	case "chmod", "chown", "chgrp":
+		if hasFlag(flags, "R", "recursive") {
+			c.result.add(Medium, fmt.Sprintf("changes permissions recursively (%s -R)", name))
+		}
+		for _, op := range operands {
+			if op == "777" || op == "a+rwx" {
+				c.result.add(Medium, "makes files writable by everyone")
+			}
+		}
+	case "find":
+		for _, arg := range args {
+			if arg == "-delete" {
+				c.result.add(High, "deletes every file find matches")
+			}
+			if arg == "-exec" || arg == "-execdir" {
+				c.result.add(Medium, "runs a command on every file find matches")
+			}
+		}
+	case "git":
+		c.git(args)
+	case "truncate":
+		c.result.add(Medium, "truncates files")
+	}
+
+	if writers[name] {
+		for _, path := range operands {
+			c.path(path, "writes")
+		}
+	}
+	if name == "dd" {
+		for _, arg := range args {
+			if path, ok := strings.CutPrefix(arg, "of="); ok {
+				c.path(path, "writes")
+			}
+		}
+	}
+}
+
+// networkOutputs returns the local files and directories a network command
+// writes to.
+func networkOutputs(name string, args, operands []string) []string {
+	switch name {
+	case "curl":
+		return optionValues(args, "o", "output", "output-dir")
+	case "wget":
+		return optionValues(args, "O", "output-document", "P", "directory-prefix")
+	case "aria2c":
+		return optionValues(args, "d", "dir")
+	case "scp", "rsync":
+		// The destination is the last operand, unless it is remote
+		if len(operands) > 1 {
+			if dest := operands[len(operands)-1]; !strings.Contains(dest, ":") {
+				return []string{dest}
+			}
+		}
+	}
+	return nil
+}
+
+// optionValues returns the values of the named options, given as "-o v",
+// "-ov", a cluster such as "-sSo v", "--output v" or "--output=v".
+// Single-letter names are short options.
+func optionValues(args []string, names ...string) []string {
+	short := make(map[rune]bool)
+	long := make(map[string]bool)
+	for _, name := range names {
+		if len(name) == 1 {
+			short[rune(name[0])] = true
+		} else {
+			long[name] = true
+		}
+	}
+
+	var values []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return values
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			if !long[name] {
+				continue
+			}
+			if hasValue {
+				values = append(values, value)
+			} else if i+1 < len(args) {
+				i++
+				values = append(values, args[i])
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j, r := range arg[1:] {
+				if !short[r] {
+					continue
+				}
+				if rest := arg[2+j:]; rest != "" {
+					values = append(values, rest)
+				} else if i+1 < len(args) {
+					i++
+					values = append(values, args[i])
+				}
+				break
+			}
+		}
+	}
+	return values
+}
+
+func (c *checker) git(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	flags, _ := splitFlags(args[1:])
+	switch args[0] {
+	case "push":
+		if hasFlag(flags, "f", "force", "force-with-lease") {
+			c.result.add(Medium, "force-pushes, rewriting remote history")
+		}
+	case "reset":
+		if hasFlag(flags, "hard") {
+			c.result.add(Medium, "discards uncommitted changes (git reset --hard)")
+		}
+	case "clean":
+		if hasFlag(flags, "f", "force") {
+			c.result.add(Medium, "deletes untracked files (git clean)")
+		}
+	case "checkout", "restore":
+		for _, arg := range args[1:] {
+			if arg == "." || arg == "--" {
+				c.result.add(Low, "may discard uncommitted changes")
+				break
+			}
+		}
+	case "clone", "fetch", "pull":
+		c.result.add(Low, "accesses the network (git "+args[0]+")")
+	}
+}
+
+// redirects checks the files a statement writes through redirections.
+func (c *checker) redirects(stmt *syntax.Stmt) {
+	for _, redir := range stmt.Redirs {
+		switch redir.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut, syntax.RdrInOut:
+			if redir.Word != nil {
+				c.path(wordString(redir.Word), "writes")
+			}
+		}
+	}
+}
+
+// path flags an operation on a file outside the working directory.
+func (c *checker) path(path, verb string) {
+	if path == "" || strings.Contains(path, "\x00") {
+		return
+	}
+	if strings.HasPrefix(path, "~") {
+		path = c.analyzer.home + strings.TrimPrefix(path, "~")
+	}
+	if !filepath.IsAbs(path) {
+		if strings.HasPrefix(path, "$") {
+			return // Unknown until the shell expands it
+		}
+		path = filepath.Join(c.dir, path)
+	}
+	path = filepath.Clean(path)
+
+	if harmless[path] {
+		return
+	}
+	if path == "/" || path == c.analyzer.home {
+		c.result.add(High, fmt.Sprintf("%s %s", verb, path))
+		return
+	}
+	if c.analyzer.home != "" {
+		for _, name := range homeConfig {
+			if within(path, filepath.Join(c.analyzer.home, name)) {
+				c.result.add(High, fmt.Sprintf("%s shell startup or credential files (%s)", verb, path))
+				return
+			}
+		}
+	}
+	// Check the temporary directory first, as it is under /var on macOS
+	if within(path, os.TempDir()) || within(path, "/tmp") {
+		return
+	}
+	for _, dir := range systemDirs {
+		if within(path, dir) {
+			c.result.add(High, fmt.Sprintf("%s system files (%s)", verb, path))
+			return
+		}
+	}
+	if !within(path, c.dir) {
+		c.result.add(Medium, fmt.Sprintf("%s outside the current directory (%s)", verb, path))
+	}
+}
+
+func within(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// firstCall returns the first simple command of a statement, looking into
+// pipelines.
+func firstCall(stmt *syntax.Stmt) *syntax.CallExpr {
+	if stmt == nil {
+		return nil
+	}
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.CallExpr:
+		return cmd
+	case *syntax.BinaryCmd:
+		return firstCall(cmd.X)
+	}
+	return nil
+}
+
+// program is the name of the program a call runs, after any wrappers.
+func program(call *syntax.CallExpr) string {
+	if call == nil {
+		return ""
+	}
+	words := wordStrings(call.Args)
+	for len(words) > 0 && wrappers[base(words[0])] {
+		words = skipWrapper(base(words[0]), words[1:])
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	return base(words[0])
+}
+
+// skipWrapper drops a wrapper's own options and arguments, returning the
+// wrapped command.
+func skipWrapper(name string, args []string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case name == "env" && strings.Contains(arg, "=") && !strings.HasPrefix(arg, "-"):
+			args = args[1:]
+		case strings.HasPrefix(arg, "-"):
+			args = args[1:]
+			// Options of sudo and xargs that take a value
+			if (name == "sudo" && (arg == "-u" || arg == "-g")) || (name == "xargs" && (arg == "-I" || arg == "-n" || arg == "-P")) {
+				if len(args) > 0 {
+					args = args[1:]
+				}
+			}
+		case (name == "timeout" || name == "watch") && arg != "" && arg[0] >= '0' && arg[0] <= '9':
+			args = args[1:]
+			name = "" // Only the duration is skipped
+		default:
+			return args
+		}
+	}
+	return args
+}
+
+// splitFlags separates options from operands. Short flags are split into
+// letters, long flags lose their dashes and any "=value".
+func splitFlags(args []string) (flags map[string]bool, operands []string) {
+	flags = make(map[string]bool)
+	endOfFlags := false
+	for _, arg := range args {
+		switch {
+		case endOfFlags || arg == "-" || !strings.HasPrefix(arg, "-"):
+			operands = append(operands, arg)
+		case arg == "--":
+			endOfFlags = true
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			flags[name] = true
+		default:
+			for _, r := range arg[1:] {
+				flags[string(r)] = true
+			}
+		}
+	}
+	return flags, operands
+}
+
+func hasFlag(flags map[string]bool, names ...string) bool {
+	for _, name := range names {
+		if flags[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExpansion reports whether any of the words holds a parameter
+// expansion or command substitution.
+func hasExpansion(words []*syntax.Word) bool {
+	found := false
+	for _, word := range words {
+		syntax.Walk(word, func(node syntax.Node) bool {
+			switch node.(type) {
+			case *syntax.ParamExp, *syntax.CmdSubst, *syntax.ProcSubst:
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+func hasSubstitution(word *syntax.Word) bool {
+	found := false
+	syntax.Walk(word, func(node syntax.Node) bool {
+		switch node.(type) {
+		case *syntax.CmdSubst, *syntax.ProcSubst:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func wordStrings(words []*syntax.Word) []string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = wordString(w)
+	}
+	return out
+}
+
+// wordString returns the text of a word with quotes removed. Parameter
+// expansions are kept as "$NAME", except that $HOME becomes "~", and
+// command substitutions as "$(...)".
+func wordString(word *syntax.Word) string {
+	var b strings.Builder
+	var parts func(ps []syntax.WordPart)
+	parts = func(ps []syntax.WordPart) {
+		for _, part := range ps {
+			switch p := part.(type) {
+			case *syntax.Lit:
+				b.WriteString(p.Value)
+			case *syntax.SglQuoted:
+				b.WriteString(p.Value)
+			case *syntax.DblQuoted:
+				parts(p.Parts)
+			case *syntax.ParamExp:
+				if p.Param != nil && p.Param.Value == "HOME" && b.Len() == 0 {
+					b.WriteString("~")
+				} else if p.Param != nil {
+					b.WriteString("$" + p.Param.Value)
+				}
+			case *syntax.CmdSubst, *syntax.ProcSubst:
+				b.WriteString("$(...)")
+			}
+		}
+	}
+	parts(word.Parts)
+	return b.String()
+}
+
+// base returns the program name of a command word such as /usr/bin/rm.
+func base(word string) string {
+	return filepath.Base(word)
+}
+
+func match(rules []*regexp.Regexp, text string) string {
+	for _, re := range rules {
+		if re.MatchString(text) {
+			return re.String()
+		}
+	}
+	return ""
+}
+
+func set(items ...string) map[string]bool {
+	m := make(map[string]bool, len(items))
+	for _, item := range items {
+		m[item] = true
+	}
+	return m
+}
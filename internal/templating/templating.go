@@ -0,0 +1,232 @@
+// Package templating renders prompt templates. Templates use [name]
+// placeholders unless they opt into Go's text/template syntax, which adds
+// defaults, conditionals and helper functions.
+package templating
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/utils"
+)
+
+const (
+	// SyntaxBrackets replaces [name] placeholders. It is the default.
+	SyntaxBrackets = "brackets"
+	// SyntaxGo renders the template with text/template.
+	SyntaxGo = "go"
+)
+
+// shellTimeout bounds how long the shell helper waits for a command.
+const shellTimeout = 30 * time.Second
+
+// ValidSyntax reports whether syntax names a known template syntax. The
+// empty string means brackets.
+func ValidSyntax(syntax string) bool {
+	return syntax == "" || syntax == SyntaxBrackets || syntax == SyntaxGo
+}
+
+// Validate reports whether content can be rendered with syntax.
+func Validate(content, syntax string) error {
+	if !ValidSyntax(syntax) {
+		return fmt.Errorf("unknown template syntax '%s'. Use '%s' or '%s'", syntax, SyntaxBrackets, SyntaxGo)
+	}
+	if syntax == SyntaxGo {
+		_, err := parseGo(content)
+		return err
+	}
+	return nil
+}
+
+// Render fills in the template. Bracket templates only replace the given
+// variables. Go templates see every variable they use, set to "" when not
+// given, so {{if .name}} and {{.name | default "x"}} work for missing ones.
+func Render(content, syntax string, vars map[string]string) (string, error) {
+	if err := Validate(content, syntax); err != nil {
+		return "", err
+	}
+	if syntax != SyntaxGo {
+		for key, value := range vars {
+			content = strings.ReplaceAll(content, "["+key+"]", value)
+		}
+		return content, nil
+	}
+
+	t, err := parseGo(content)
+	if err != nil {
+		return "", err
+	}
+	data := make(map[string]interface{})
+	for _, name := range goVariables(t) {
+		data[name] = ""
+	}
+	for key, value := range vars {
+		data[key] = value
+	}
+
+	var out bytes.Buffer
+	if err := t.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Variables returns the names of the variables the template uses, in order
+// of first use. A Go template that doesn't parse has none.
+func Variables(content, syntax string) []string {
+	if syntax != SyntaxGo {
+		return utils.ExtractVariables(content)
+	}
+	t, err := parseGo(content)
+	if err != nil {
+		return nil
+	}
+	return goVariables(t)
+}
+
+func parseGo(content string) (*template.Template, error) {
+	return template.New("template").Funcs(funcs()).Option("missingkey=zero").Parse(content)
+}
+
+// funcs are the helpers available to Go templates.
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		"default": defaultValue,
+		"env":     os.Getenv,
+		"file":    readFile,
+		"shell":   shellOutput,
+		"date":    date,
+		"stdin":   utils.ReadStdin,
+	}
+}
+
+// defaultValue returns value, or def when value is empty. It takes def first
+// so it can end a pipeline: {{.language | default "Go"}}.
+func defaultValue(def, value interface{}) interface{} {
+	if value == nil || value == "" {
+		return def
+	}
+	return value
+}
+
+func readFile(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// shellOutput runs command with sh and returns its output without the
+// trailing newline.
+func shellOutput(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shellTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", command, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// date formats the current time with a Go layout, 2006-01-02 by default.
+func date(layout ...string) string {
+	if len(layout) > 0 {
+		return time.Now().Format(layout[0])
+	}
+	return time.Now().Format("2006-01-02")
+}
+
+// goVariables collects the top-level fields the template refers to. Fields
+// inside range and with blocks are relative to another value and skipped.
+func goVariables(t *template.Template) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			add(n.Ident[0])
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+
+	if t.Tree != nil {
+		walk(t.Tree.Root)
+	}
+	// Then any {{define}}d templates, in a stable order
+	var defined []*template.Template
+	for _, tmpl := range t.Templates() {
+		if tmpl != t && tmpl.Tree != nil {
+			defined = append(defined, tmpl)
+		}
+	}
+	sort.Slice(defined, func(i, j int) bool { return defined[i].Name() < defined[j].Name() })
+	for _, tmpl := range defined {
+		walk(tmpl.Tree.Root)
+	}
+	return names
+}
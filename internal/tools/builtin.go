@@ -0,0 +1,370 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tedfulk/suggest/internal/api"
+)
+
+const (
+	maxGrepMatches  = 100
+	maxGrepFileSize = 1024 * 1024
+	shellTimeout    = 2 * time.Minute
+)
+
+// Options configures the built-in tools.
+type Options struct {
+	// Root restricts the file tools to a directory; empty allows any path
+	Root string
+	// TavilyAPIKey enables the web_search tool
+	TavilyAPIKey string
+	// ConfirmShell is asked before run_shell executes a command. Commands are
+	// refused when it is nil or returns false.
+	ConfirmShell func(command string) bool
+}
+
+// Builtin returns the local tools: read_file, list_dir, grep, run_shell and,
+// when a Tavily key is set, web_search.
+func Builtin(opts Options) []Tool {
+	tools := append(ReadOnly(opts.Root), Tool{
+		Name:        "run_shell",
+		Description: "Run a shell command and return its output. The user is asked to approve every command.",
+		Parameters: Object(map[string]string{
+			"command": "The command to run with sh -c",
+		}, "command"),
+		Run: func(args map[string]interface{}) (string, error) {
+			return runShell(args, opts.ConfirmShell)
+		},
+	})
+
+	if opts.TavilyAPIKey != "" {
+		tools = append(tools, Tool{
+			Name:        "web_search",
+			Description: "Search the web and return a summary answer with the top results",
+			Parameters: Object(map[string]string{
+				"query": "The search query",
+			}, "query"),
+			Run: func(args map[string]interface{}) (string, error) {
+				return webSearch(args, opts.TavilyAPIKey)
+			},
+		})
+	}
+	return tools
+}
+
+// ReadOnly returns the tools that only read files: read_file, list_dir and
+// grep. Paths are restricted to root unless it is empty.
+func ReadOnly(root string) []Tool {
+	return []Tool{
+		{
+			Name:        "read_file",
+			Description: "Read the contents of a text file",
+			Parameters: Object(map[string]string{
+				"path": "Path of the file, relative to the current directory",
+			}, "path"),
+			Run: func(args map[string]interface{}) (string, error) {
+				return readFile(root, args)
+			},
+		},
+		{
+			Name:        "list_dir",
+			Description: "List the files and directories in a directory",
+			Parameters: Object(map[string]string{
+				"path": "Directory to list, defaults to the current directory",
+			}),
+			Run: func(args map[string]interface{}) (string, error) {
+				return listDir(root, args)
+			},
+		},
+		{
+			Name:        "grep",
+			Description: "Search files for lines matching a regular expression, recursively",
+			Parameters: Object(map[string]string{
+				"pattern": "Regular expression (Go RE2 syntax) to search for",
+				"path":    "File or directory to search, defaults to the current directory",
+				"glob":    "Only search files whose name matches this glob, e.g. *.go",
+			}, "pattern"),
+			Run: func(args map[string]interface{}) (string, error) {
+				return grep(root, args)
+			},
+		},
+	}
+}
+
+// ResolvePath resolves path against root and makes sure the result, after
+// following symlinks, stays inside root. An empty root allows any path.
+func ResolvePath(root, path string) (string, error) {
+	if root == "" {
+		return path, nil
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(root, resolved)
+	}
+	resolved = filepath.Clean(resolved)
+
+	if !within(root, resolved) {
+		return "", fmt.Errorf("%s is outside %s", path, root)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	real, err := evalExistingSymlinks(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !within(realRoot, real) {
+		return "", fmt.Errorf("%s links outside %s", path, root)
+	}
+	return resolved, nil
+}
+
+// evalExistingSymlinks follows the symlinks of the nearest existing
+// ancestor of path and joins the rest of path onto it, so paths of files
+// that are about to be created are checked too.
+func evalExistingSymlinks(path string) (string, error) {
+	var rest []string
+	dir := path
+	for {
+		real, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+		dir = parent
+	}
+}
+
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// displayPath shows paths relative to root when the tools are scoped.
+func displayPath(root, path string) string {
+	if root == "" {
+		return path
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		if rel, err := filepath.Rel(abs, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+func readFile(root string, args map[string]interface{}) (string, error) {
+	path, err := StringArg(args, "path", true)
+	if err != nil {
+		return "", err
+	}
+	path, err = ResolvePath(root, path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if isBinary(data) {
+		return "", fmt.Errorf("%s is a binary file", path)
+	}
+	return string(data), nil
+}
+
+func listDir(root string, args map[string]interface{}) (string, error) {
+	path, err := StringArg(args, "path", false)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = "."
+	}
+	path, err = ResolvePath(root, path)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		if e.IsDir() {
+			fmt.Fprintf(&b, "%s/\n", e.Name())
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			fmt.Fprintf(&b, "%s\n", e.Name())
+			continue
+		}
+		fmt.Fprintf(&b, "%s (%d bytes)\n", e.Name(), info.Size())
+	}
+	if b.Len() == 0 {
+		return "(empty directory)", nil
+	}
+	return b.String(), nil
+}
+
+func grep(root string, args map[string]interface{}) (string, error) {
+	pattern, err := StringArg(args, "pattern", true)
+	if err != nil {
+		return "", err
+	}
+	dir, _ := StringArg(args, "path", false)
+	if dir == "" {
+		dir = "."
+	}
+	dir, err = ResolvePath(root, dir)
+	if err != nil {
+		return "", err
+	}
+	glob, _ := StringArg(args, "glob", false)
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	var matches []string
+	errLimit := errors.New("match limit reached")
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			// Skip hidden directories such as .git
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if glob != "" {
+			if ok, _ := filepath.Match(glob, d.Name()); !ok {
+				return nil
+			}
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxGrepFileSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), maxGrepFileSize)
+		for line := 1; scanner.Scan(); line++ {
+			if re.MatchString(scanner.Text()) {
+				matches = append(matches, fmt.Sprintf("%s:%d: %s", displayPath(root, path), line, strings.TrimSpace(scanner.Text())))
+				if len(matches) >= maxGrepMatches {
+					return errLimit
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errLimit) {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return "No matches found", nil
+	}
+	output := strings.Join(matches, "\n")
+	if errors.Is(err, errLimit) {
+		output += fmt.Sprintf("\n... (stopped after %d matches)", maxGrepMatches)
+	}
+	return output, nil
+}
+
+func runShell(args map[string]interface{}, confirm func(string) bool) (string, error) {
+	command, err := StringArg(args, "command", true)
+	if err != nil {
+		return "", err
+	}
+	if confirm == nil || !confirm(command) {
+		return "The user declined to run this command.", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shellTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+	result := string(output)
+	if ctx.Err() == context.DeadlineExceeded {
+		return result + fmt.Sprintf("\n(command timed out after %s)", shellTimeout), nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return result + fmt.Sprintf("\n(exit status %d)", exitErr.ExitCode()), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if result == "" {
+		return "(no output)", nil
+	}
+	return result, nil
+}
+
+func webSearch(args map[string]interface{}, apiKey string) (string, error) {
+	query, err := StringArg(args, "query", true)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := api.NewTavilyClient(apiKey).Search(query)
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(resp.Results, func(i, j int) bool {
+		return resp.Results[i].Score > resp.Results[j].Score
+	})
+
+	var b strings.Builder
+	if resp.Answer != "" {
+		fmt.Fprintf(&b, "Answer: %s\n\n", resp.Answer)
+	}
+	for _, r := range resp.Results {
+		fmt.Fprintf(&b, "%s\n%s\n%s\n\n", r.Title, r.URL, r.Content)
+	}
+	return b.String(), nil
+}
+
+// isBinary reports whether data looks like a binary file.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tedfulk/suggest/internal/api"
+)
+
+// maxOutputBytes caps what a single tool call returns to the model.
+const maxOutputBytes = 32 * 1024
+
+// Tool is a function the model can call.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is a JSON schema object describing the arguments
+	Parameters map[string]interface{}
+	Run        func(args map[string]interface{}) (string, error)
+}
+
+// Registry holds the tools offered to the model.
+type Registry struct {
+	tools []Tool
+}
+
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{}
+	r.Add(tools...)
+	return r
+}
+
+// Add registers tools, replacing any existing tool with the same name.
+func (r *Registry) Add(tools ...Tool) {
+	for _, t := range tools {
+		if i := r.index(t.Name); i >= 0 {
+			r.tools[i] = t
+			continue
+		}
+		r.tools = append(r.tools, t)
+	}
+}
+
+func (r *Registry) index(name string) int {
+	for i, t := range r.tools {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Registry) Len() int {
+	return len(r.tools)
+}
+
+// Definitions returns the tools in the format sent to the providers.
+func (r *Registry) Definitions() []api.Tool {
+	var defs []api.Tool
+	for _, t := range r.tools {
+		defs = append(defs, api.Tool{
+			Type: "function",
+			Function: api.ToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return defs
+}
+
+// Call runs the tool a model asked for and returns its output.
+func (r *Registry) Call(call api.ToolCall) (string, error) {
+	i := r.index(call.Function.Name)
+	if i < 0 {
+		return "", fmt.Errorf("unknown tool '%s'", call.Function.Name)
+	}
+
+	args := make(map[string]interface{})
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments for %s: %w", call.Function.Name, err)
+		}
+	}
+
+	output, err := r.tools[i].Run(args)
+	if err != nil {
+		return "", err
+	}
+	return truncate(output), nil
+}
+
+// truncate cuts s to maxOutputBytes, on a rune boundary so multi-byte
+// characters aren't split.
+func truncate(s string) string {
+	if len(s) <= maxOutputBytes {
+		return s
+	}
+	n := maxOutputBytes
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + fmt.Sprintf("\n... (output truncated, %d bytes omitted)", len(s)-n)
+}
+
+// Object builds a JSON schema for an object whose properties are all
+// strings, described by props.
+func Object(props map[string]string, required ...string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for name, description := range props {
+		properties[name] = map[string]interface{}{
+			"type":        "string",
+			"description": description,
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// StringArg returns a string argument, or an error if a required one is
+// missing.
+func StringArg(args map[string]interface{}, name string, required bool) (string, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		if required {
+			return "", fmt.Errorf("missing argument '%s'", name)
+		}
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprint(v), nil
+	}
+	if required && s == "" {
+		return "", fmt.Errorf("missing argument '%s'", name)
+	}
+	return s, nil
+}
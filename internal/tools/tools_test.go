@@ -0,0 +1,23 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateKeepsRunes(t *testing.T) {
+	// "é" is two bytes, so an odd byte limit falls inside one
+	s := "x" + strings.Repeat("é", maxOutputBytes)
+	got := truncate(s)
+	if !utf8.ValidString(got) {
+		t.Fatal("truncate split a multi-byte character")
+	}
+	kept, _, ok := strings.Cut(got, "\n... (output truncated")
+	if !ok || len(kept) != maxOutputBytes-1 {
+		t.Errorf("kept %d bytes, want %d", len(kept), maxOutputBytes-1)
+	}
+	if short := "héllo"; truncate(short) != short {
+		t.Errorf("truncate(%q) = %q", short, truncate(short))
+	}
+}
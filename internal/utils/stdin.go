@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	stdinOnce    sync.Once
+	stdinContent string
+	stdinErr     error
+)
+
+// ReadStdin returns the content piped into the process, trimmed of
+// surrounding whitespace, or "" when stdin is a terminal. Stdin is read
+// once, so the message and templates can both use it.
+func ReadStdin() (string, error) {
+	stdinOnce.Do(func() {
+		stat, err := os.Stdin.Stat()
+		if err != nil || stat.Mode()&os.ModeCharDevice != 0 {
+			return
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			stdinErr = fmt.Errorf("error reading from stdin: %w", err)
+			return
+		}
+		stdinContent = strings.TrimSpace(string(data))
+	})
+	return stdinContent, stdinErr
+}
+
+// IgnoreStdin makes ReadStdin return "" from now on, for commands whose
+// stdin carries something other than a message.
+func IgnoreStdin() {
+	stdinOnce.Do(func() {})
+}
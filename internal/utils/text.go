@@ -11,11 +11,18 @@ func TruncateText(text string, length int) string {
 	return text[:length-3] + "..."
 }
 
+// variablePattern matches [name] placeholders. Any text in brackets is a
+// name, such as [target language] or [file.ext]; templates with brackets in
+// code samples should use the Go syntax instead.
+var variablePattern = regexp.MustCompile(`\[(.*?)\]`)
+
+// ExtractVariables returns the [name] placeholders in content, in order of
+// first use.
 func ExtractVariables(content string) []string {
 	var vars []string
 	varMap := make(map[string]bool)
 
-	matches := regexp.MustCompile(`\[(.*?)\]`).FindAllStringSubmatch(content, -1)
+	matches := variablePattern.FindAllStringSubmatch(content, -1)
 	for _, match := range matches {
 		if len(match) > 1 && !varMap[match[1]] {
 			vars = append(vars, match[1])